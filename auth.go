@@ -0,0 +1,108 @@
+package goz
+
+import (
+	"encoding/base64"
+	"net/http"
+)
+
+// Authenticator add credentials to requests, it's called before every attempt
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Challenger authenticator answering 401 responses, the request is sent
+// once more with new credentials if Challenge returns true
+type Challenger interface {
+	Challenge(req *http.Request, resp *http.Response) (bool, error)
+}
+
+// AuthenticatorFunc use a function as Authenticator
+type AuthenticatorFunc func(req *http.Request) error
+
+// Authenticate implement Authenticator
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// BasicAuth http basic authentication
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+		return nil
+	})
+}
+
+// BearerAuth bearer token authentication
+func BearerAuth(token string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// APIKeyAuth send api key in header name
+func APIKeyAuth(name, key string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set(name, key)
+		return nil
+	})
+}
+
+// APIKeyQueryAuth send api key in query parameter name
+func APIKeyQueryAuth(name, key string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		q := ParseOrderedValues(req.URL.RawQuery)
+		q.Set(name, key)
+		req.URL.RawQuery = q.Encode()
+		return nil
+	})
+}
+
+// roundTrip send the request once, answering an auth challenge
+func (c *call) roundTrip() (*http.Response, error) {
+	if err := c.authorize(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.cli.Do(c.req)
+
+	challenger, ok := c.opts.Auth.(Challenger)
+	if err != nil || !ok || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	retry, err := challenger.Challenge(c.req, resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if !retry || !c.replayable() {
+		return resp, nil
+	}
+	if err := c.rewind(resp); err != nil {
+		return nil, err
+	}
+
+	if err := c.authorize(); err != nil {
+		return nil, err
+	}
+
+	return c.cli.Do(c.req)
+}
+
+// authorize add credentials then sign the request
+func (c *call) authorize() error {
+	if c.opts.Auth != nil {
+		if err := c.opts.Auth.Authenticate(c.req); err != nil {
+			return err
+		}
+	}
+
+	if c.opts.Signer != nil {
+		if err := c.opts.Signer.Sign(c.req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
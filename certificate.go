@@ -0,0 +1,137 @@
+package goz
+
+import (
+	"crypto"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// hasClientCertificateFiles get if options load client certificate from disk
+func hasClientCertificateFiles(opts Options) bool {
+	return opts.CertFile != "" || opts.PKCS12File != ""
+}
+
+// loadClientCertificate load client certificate from pem files or pkcs12 bundle
+func loadClientCertificate(opts Options) (*tls.Certificate, error) {
+	if opts.PKCS12File != "" {
+		data, err := ioutil.ReadFile(opts.PKCS12File)
+		if err != nil {
+			return nil, fmt.Errorf("read pkcs12 file failed: %v", err)
+		}
+
+		return parsePKCS12(data, opts.PKCS12Password)
+	}
+
+	keyFile := opts.KeyFile
+	if keyFile == "" {
+		// key in the same file as the certificate
+		keyFile = opts.CertFile
+	}
+
+	cert, err := tls.LoadX509KeyPair(opts.CertFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load client certificate failed: %v", err)
+	}
+
+	return &cert, nil
+}
+
+// parsePKCS12 parse pkcs12 bundle, the first certificate is the leaf
+// and the following ones its chain
+func parsePKCS12(data []byte, password string) (*tls.Certificate, error) {
+	key, leaf, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("decode pkcs12 failed: %v", err)
+	}
+
+	// the leaf must be the certificate of the private key
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported pkcs12 private key")
+	}
+	if pub, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); ok && !pub.Equal(signer.Public()) {
+		return nil, errors.New("pkcs12 private key doesn't match the leaf certificate")
+	}
+
+	cert := &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	for _, ca := range caCerts {
+		cert.Certificate = append(cert.Certificate, ca.Raw)
+	}
+
+	return cert, nil
+}
+
+// certReloader reload client certificate when its files are rotated on disk,
+// the certificate is checked on each tls handshake
+type certReloader struct {
+	opts Options
+
+	mu    sync.Mutex
+	cert  *tls.Certificate
+	stamp string
+}
+
+func newCertReloader(opts Options) (*certReloader, error) {
+	l := &certReloader{opts: opts}
+
+	if err := l.reload(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// GetClientCertificate implement tls.Config.GetClientCertificate
+func (l *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.filesStamp() != l.stamp {
+		// keep using the previous certificate if the rotated one is not readable yet
+		l.reload()
+	}
+
+	return l.cert, nil
+}
+
+func (l *certReloader) reload() error {
+	stamp := l.filesStamp()
+
+	cert, err := loadClientCertificate(l.opts)
+	if err != nil {
+		return err
+	}
+
+	l.cert = cert
+	l.stamp = stamp
+
+	return nil
+}
+
+// filesStamp modification time and size of the certificate files
+func (l *certReloader) filesStamp() string {
+	stamp := ""
+	for _, file := range []string{l.opts.CertFile, l.opts.KeyFile, l.opts.PKCS12File} {
+		if file == "" {
+			continue
+		}
+		fi, err := os.Stat(file)
+		if err != nil {
+			stamp += file + ":missing;"
+			continue
+		}
+		stamp += fmt.Sprintf("%s:%d:%d;", file, fi.ModTime().UnixNano(), fi.Size())
+	}
+
+	return stamp
+}
@@ -0,0 +1,80 @@
+package goz
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"strings"
+)
+
+// Codec encode request payload and decode response body of a content type,
+// register codecs like msgpack, protobuf, yaml or cbor with Request.RegisterCodec
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	ContentType() string
+}
+
+// jsonCodec built-in json codec
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+// xmlCodec built-in xml codec
+type xmlCodec struct{}
+
+func (xmlCodec) Marshal(v interface{}) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlCodec) Unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
+
+func (xmlCodec) ContentType() string {
+	return "application/xml"
+}
+
+// defaultCodecs codecs registered to every client
+func defaultCodecs() map[string]Codec {
+	return map[string]Codec{
+		"application/json": jsonCodec{},
+		"application/xml":  xmlCodec{},
+		"text/xml":         xmlCodec{},
+	}
+}
+
+// RegisterCodec register codec for its content type, replacing the existing one
+func (r *Request) RegisterCodec(codec Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// copy on write, requests in flight keep the registry they started with
+	codecs := defaultCodecs()
+	for k, v := range r.codecs {
+		codecs[k] = v
+	}
+	codecs[mediaType(codec.ContentType())] = codec
+
+	r.codecs = codecs
+}
+
+// mediaType get lower case media type without parameters
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	}
+
+	return strings.ToLower(mt)
+}
@@ -0,0 +1,327 @@
+package goz
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// CookieJar public suffix aware cookie jar that can be saved to
+// and loaded from disk as json or netscape cookies.txt
+type CookieJar struct {
+	jar *cookiejar.Jar
+
+	mu      sync.Mutex
+	entries map[string]*jarEntry
+}
+
+// jarEntry cookie and the url it was set from
+type jarEntry struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// NewCookieJar new cookie jar object
+func NewCookieJar() (*CookieJar, error) {
+	jar, err := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CookieJar{
+		jar:     jar,
+		entries: map[string]*jarEntry{},
+	}, nil
+}
+
+// SetCookies implement http.CookieJar
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	for _, c := range cookies {
+		cookie := *c
+
+		// keep max age as absolute expiry so it survives saving
+		if cookie.MaxAge > 0 {
+			cookie.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+			cookie.MaxAge = 0
+		}
+
+		key := cookieKey(u, &cookie)
+		if cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(now)) {
+			delete(j.entries, key)
+			continue
+		}
+
+		j.entries[key] = &jarEntry{
+			URL:    (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String(),
+			Cookie: &cookie,
+		}
+	}
+}
+
+// Cookies implement http.CookieJar
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// SaveJSON save unexpired cookies to path as json
+func (j *CookieJar) SaveJSON(path string) error {
+	b, err := json.MarshalIndent(j.validEntries(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, b)
+}
+
+// LoadJSON load cookies saved by SaveJSON
+func (j *CookieJar) LoadJSON(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var entries []*jarEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return fmt.Errorf("parse cookies file failed: %v", err)
+	}
+
+	for _, e := range entries {
+		u, err := url.Parse(e.URL)
+		if err != nil || e.Cookie == nil {
+			continue
+		}
+		j.SetCookies(u, []*http.Cookie{e.Cookie})
+	}
+
+	return nil
+}
+
+// SaveNetscape save unexpired cookies to path in netscape cookies.txt format
+func (j *CookieJar) SaveNetscape(path string) error {
+	var b strings.Builder
+	b.WriteString("# Netscape HTTP Cookie File\n")
+
+	for _, e := range j.validEntries() {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			continue
+		}
+		c := e.Cookie
+
+		domain, subdomains := u.Hostname(), "FALSE"
+		if c.Domain != "" {
+			domain, subdomains = "."+strings.TrimPrefix(c.Domain, "."), "TRUE"
+		}
+		if c.HttpOnly {
+			domain = "#HttpOnly_" + domain
+		}
+
+		path := c.Path
+		if path == "" {
+			path = defaultCookiePath(u.Path)
+		}
+
+		secure := "FALSE"
+		if c.Secure {
+			secure = "TRUE"
+		}
+
+		var expires int64
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Unix()
+		}
+
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, subdomains, path, secure, expires, c.Name, c.Value)
+	}
+
+	return writeFileAtomic(path, []byte(b.String()))
+}
+
+// LoadNetscape load cookies from a netscape cookies.txt file
+func (j *CookieJar) LoadNetscape(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+			httpOnly = true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("invalid cookies.txt line: %q", line)
+		}
+
+		cookie := &http.Cookie{
+			Path:     fields[2],
+			Secure:   fields[3] == "TRUE",
+			HttpOnly: httpOnly,
+			Name:     fields[5],
+			Value:    fields[6],
+		}
+
+		host := strings.TrimPrefix(fields[0], ".")
+		if fields[1] == "TRUE" {
+			cookie.Domain = host
+		}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+
+		j.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: cookie.Path}, []*http.Cookie{cookie})
+	}
+
+	return scanner.Err()
+}
+
+// validEntries get unexpired cookies
+func (j *CookieJar) validEntries() []*jarEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	entries := make([]*jarEntry, 0, len(j.entries))
+	for _, e := range j.entries {
+		if !e.Cookie.Expires.IsZero() && e.Cookie.Expires.Before(now) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// SaveCookies save cookies of the client session, path ending with .json is saved
+// as json, others in netscape cookies.txt format
+func (r *Request) SaveCookies(path string) error {
+	jar, err := r.persistentJar()
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return jar.SaveJSON(path)
+	}
+
+	return jar.SaveNetscape(path)
+}
+
+// LoadCookies load cookies saved by SaveCookies into the client session
+func (r *Request) LoadCookies(path string) error {
+	jar, err := r.persistentJar()
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return jar.LoadJSON(path)
+	}
+
+	return jar.LoadNetscape(path)
+}
+
+// CookieJar get the cookie jar of the client session
+func (r *Request) CookieJar() http.CookieJar {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.jar
+}
+
+func (r *Request) persistentJar() (*CookieJar, error) {
+	jar, ok := r.CookieJar().(*CookieJar)
+	if !ok {
+		return nil, errors.New("client has no session cookie jar, set Options.Session")
+	}
+
+	return jar, nil
+}
+
+// cookieKey identify cookie by domain, path and name
+func cookieKey(u *url.URL, c *http.Cookie) string {
+	domain := c.Domain
+	if domain == "" {
+		domain = u.Hostname()
+	}
+	path := c.Path
+	if path == "" {
+		path = defaultCookiePath(u.Path)
+	}
+
+	return strings.ToLower(strings.TrimPrefix(domain, ".")) + ";" + path + ";" + c.Name
+}
+
+// defaultCookiePath default cookie path of request path, RFC 6265 section 5.1.4
+func defaultCookiePath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+
+	return path[:i]
+}
+
+// writeFileAtomic write file through a temp file renamed into place
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0600)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
+}
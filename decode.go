@@ -0,0 +1,97 @@
+package goz
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Decode decode response body into v, the decoder is chosen by content type
+// from the codecs registered to the client, json and xml are built in
+func (r *Response) Decode(v interface{}) error {
+	contentType := strings.ToLower(r.GetHeaderLine("content-type"))
+
+	if codec, ok := r.codecs[mediaType(contentType)]; ok {
+		switch codec.(type) {
+		case jsonCodec:
+			return r.DecodeJSON(v)
+		case xmlCodec:
+			return r.DecodeXML(v)
+		}
+
+		if err := codec.Unmarshal(r.body, v); err != nil {
+			return &DecodeError{
+				Format:      codec.ContentType(),
+				ContentType: contentType,
+				Err:         err,
+			}
+		}
+
+		return nil
+	}
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		return r.DecodeJSON(v)
+	case strings.Contains(contentType, "xml"):
+		return r.DecodeXML(v)
+	}
+
+	return &DecodeError{
+		ContentType: contentType,
+		Err:         fmt.Errorf("unsupported content type %q", contentType),
+	}
+}
+
+// DecodeJSON decode json response body into v
+func (r *Response) DecodeJSON(v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(r.body))
+	if r.strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decoder.Decode(v); err != nil {
+		return &DecodeError{
+			Format:      "json",
+			ContentType: r.GetHeaderLine("content-type"),
+			Err:         err,
+		}
+	}
+
+	return nil
+}
+
+// DecodeXML decode xml response body into v
+func (r *Response) DecodeXML(v interface{}) error {
+	if err := xml.Unmarshal(r.body, v); err != nil {
+		return &DecodeError{
+			Format:      "xml",
+			ContentType: r.GetHeaderLine("content-type"),
+			Err:         err,
+		}
+	}
+
+	return nil
+}
+
+// decodeResult decode response body into Result for 2xx responses
+// and into ErrorResult for 4xx and 5xx responses, responses without body
+// such as 204 and 304 are left undecoded
+func (r *Response) decodeResult(result, errorResult interface{}) error {
+	code := r.GetStatusCode()
+	if len(r.body) == 0 || code == http.StatusNoContent || code == http.StatusNotModified {
+		return nil
+	}
+
+	switch {
+	case code >= 200 && code < 300 && result != nil:
+		return r.Decode(result)
+	case code >= 400 && errorResult != nil:
+		return r.Decode(errorResult)
+	}
+
+	return nil
+}
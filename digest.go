@@ -0,0 +1,295 @@
+package goz
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// digestAlgorithms supported digest algorithms, strongest first
+var digestAlgorithms = []string{"SHA-512-256", "SHA-256", "MD5"}
+
+// digestAuth rfc 7616 digest authentication, the server challenge is cached
+// per host so following requests are authenticated without a 401 round trip
+type digestAuth struct {
+	username string
+	password string
+
+	mu         sync.Mutex
+	challenges map[string]*digestChallenge
+}
+
+// digestChallenge server challenge and the nonce count sent with it
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	userhash  bool
+	nc        int
+}
+
+// DigestAuth rfc 7616 digest authentication, the 401 challenge is answered automatically
+func DigestAuth(username, password string) Authenticator {
+	return &digestAuth{
+		username:   username,
+		password:   password,
+		challenges: map[string]*digestChallenge{},
+	}
+}
+
+// Authenticate implement Authenticator
+func (d *digestAuth) Authenticate(req *http.Request) error {
+	d.mu.Lock()
+	ch, ok := d.challenges[req.URL.Host]
+	if !ok {
+		d.mu.Unlock()
+		// wait for the challenge
+		return nil
+	}
+	ch.nc++
+	c := *ch
+	d.mu.Unlock()
+
+	cnonce, err := digestCnonce()
+	if err != nil {
+		return err
+	}
+
+	h := digestHash(c.algorithm)
+	uri := req.URL.RequestURI()
+
+	username := d.username
+	if c.userhash {
+		username = h(d.username + ":" + c.realm)
+	}
+
+	ha1 := h(d.username + ":" + c.realm + ":" + d.password)
+	if strings.HasSuffix(c.algorithm, "-sess") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+
+	ha2 := h(req.Method + ":" + uri)
+	if c.qop == "auth-int" {
+		body, err := digestBody(req)
+		if err != nil {
+			return err
+		}
+		ha2 = h(req.Method + ":" + uri + ":" + h(body))
+	}
+
+	nc := fmt.Sprintf("%08x", c.nc)
+
+	var response string
+	if c.qop == "" {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + c.nonce + ":" + nc + ":" + cnonce + ":" + c.qop + ":" + ha2)
+	}
+
+	params := []string{
+		"username=" + digestQuote(username),
+		"realm=" + digestQuote(c.realm),
+		"uri=" + digestQuote(uri),
+		"algorithm=" + c.algorithm,
+		"nonce=" + digestQuote(c.nonce),
+	}
+	if c.qop != "" {
+		params = append(params,
+			"nc="+nc,
+			"cnonce="+digestQuote(cnonce),
+			"qop="+c.qop,
+		)
+	}
+	params = append(params, "response="+digestQuote(response))
+	if c.opaque != "" {
+		params = append(params, "opaque="+digestQuote(c.opaque))
+	}
+	if c.userhash {
+		params = append(params, "userhash=true")
+	}
+
+	req.Header.Set("Authorization", "Digest "+strings.Join(params, ", "))
+
+	return nil
+}
+
+// Challenge implement Challenger, a rejected answer is retried only if the nonce is stale
+func (d *digestAuth) Challenge(req *http.Request, resp *http.Response) (bool, error) {
+	var (
+		best  *digestChallenge
+		stale bool
+		rank  = len(digestAlgorithms)
+	)
+
+	for _, header := range resp.Header[http.CanonicalHeaderKey("WWW-Authenticate")] {
+		if len(header) < 7 || !strings.EqualFold(header[:7], "Digest ") {
+			continue
+		}
+
+		params := parseAuthParams(header[7:])
+
+		algorithm := params["algorithm"]
+		if algorithm == "" {
+			algorithm = "MD5"
+		}
+		r := digestRank(algorithm)
+		if r < 0 || r >= rank {
+			continue
+		}
+
+		qop := ""
+		if v, ok := params["qop"]; ok {
+			for _, q := range strings.Split(v, ",") {
+				q = strings.TrimSpace(q)
+				if q == "auth" || (q == "auth-int" && qop == "") {
+					qop = q
+				}
+			}
+			if qop == "" {
+				continue
+			}
+		}
+
+		best = &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: algorithm,
+			qop:       qop,
+			userhash:  strings.EqualFold(params["userhash"], "true"),
+		}
+		stale = strings.EqualFold(params["stale"], "true")
+		rank = r
+	}
+
+	if best == nil {
+		return false, nil
+	}
+
+	d.mu.Lock()
+	d.challenges[req.URL.Host] = best
+	d.mu.Unlock()
+
+	// credentials were rejected unless the nonce expired
+	sent := strings.HasPrefix(req.Header.Get("Authorization"), "Digest ")
+
+	return !sent || stale, nil
+}
+
+// digestQuote quote a digest param value
+func digestQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// digestRank index of algorithm in digestAlgorithms, -1 if unsupported
+func digestRank(algorithm string) int {
+	name := strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS")
+	for i, a := range digestAlgorithms {
+		if a == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// digestHash hex hash function of algorithm
+func digestHash(algorithm string) func(string) string {
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "SHA-512-256":
+		newHash = sha512.New512_256
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		newHash = md5.New
+	}
+
+	return func(s string) string {
+		h := newHash()
+		io.WriteString(h, s)
+		return hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// digestCnonce random client nonce
+func digestCnonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// digestBody request body for auth-int, read from a replayable copy
+func digestBody(req *http.Request) (string, error) {
+	if req.GetBody == nil {
+		return "", nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	b, err := ioutil.ReadAll(body)
+	return string(b), err
+}
+
+// parseAuthParams parse comma separated auth params, values may be quoted
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return params
+		}
+
+		i := strings.IndexByte(s, '=')
+		if i < 0 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:i]))
+		s = strings.TrimLeft(s[i+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			j := 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			value = b.String()
+			if j < len(s) {
+				j++
+			}
+			s = s[j:]
+		} else {
+			j := strings.IndexByte(s, ',')
+			if j < 0 {
+				j = len(s)
+			}
+			value = strings.TrimSpace(s[:j])
+			s = s[j:]
+		}
+
+		params[key] = value
+	}
+}
@@ -0,0 +1,257 @@
+package goz
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumError downloaded file doesn't match Options.Checksum
+type ChecksumError struct {
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+// Error implement error interface
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+// Download download uri to path, see DownloadCtx
+func (r *Request) Download(uri, path string, opts ...Options) (*Response, error) {
+	return r.DownloadCtx(context.Background(), uri, path, opts...)
+}
+
+// DownloadCtx download uri to path with context.
+// the body is streamed to path.part which is renamed to path when finished,
+// a partial file left by a previous download is resumed with a range request
+// if the server supports it and the ETag or Last-Modified saved in
+// path.part.meta still matches, otherwise the download starts over.
+// Timeout only limits the wait for the response headers, cancel ctx
+// to stop a download that takes too long
+func (r *Request) DownloadCtx(ctx context.Context, uri, path string, opts ...Options) (*Response, error) {
+	r.mu.RLock()
+	merged := mergeOptions(r.opts, opts...)
+	r.mu.RUnlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	part := path + ".part"
+	meta := path + ".part.meta"
+
+	var offset int64
+	if fi, err := os.Stat(part); err == nil {
+		offset = fi.Size()
+	}
+
+	if merged.DownloadSegments > 1 {
+		resp, ok, err := r.downloadSegments(ctx, uri, path, merged, opts)
+		if ok {
+			return resp, err
+		}
+		// ranges not supported, fall back to a single stream
+	}
+
+	rangeHeader, ifRange := "", ""
+	if offset > 0 {
+		// resume only if the file didn't change since the partial download,
+		// without a validator there is no way to tell so start over
+		if validator, err := ioutil.ReadFile(meta); err == nil && len(validator) > 0 {
+			rangeHeader = fmt.Sprintf("bytes=%d-", offset)
+			ifRange = string(validator)
+		} else {
+			offset = 0
+		}
+	}
+
+	resp, err := r.rangeRequest(ctx, uri, merged, opts, rangeHeader, ifRange)
+	if err == nil && resp.GetStatusCode() == http.StatusPartialContent {
+		if start, ok := parseContentRangeStart(resp.GetHeaderLine("Content-Range")); !ok || start != offset {
+			// the range doesn't continue the partial file, start over
+			resp.BodyReader().Close()
+			offset = 0
+			resp, err = r.rangeRequest(ctx, uri, merged, opts, "", "")
+		}
+	}
+	if err != nil {
+		if errors.Is(err, &StatusError{StatusCode: http.StatusRequestedRangeNotSatisfiable}) {
+			os.Remove(part)
+			os.Remove(meta)
+		}
+		return resp, err
+	}
+
+	body := resp.BodyReader()
+	defer body.Close()
+
+	flag := os.O_CREATE | os.O_WRONLY
+	switch resp.GetStatusCode() {
+	case http.StatusPartialContent, http.StatusOK:
+		if resp.GetStatusCode() == http.StatusPartialContent && offset > 0 {
+			flag |= os.O_APPEND
+			break
+		}
+
+		// range not supported or file changed, start over
+		flag |= os.O_TRUNC
+		offset = 0
+
+		if err := ioutil.WriteFile(meta, []byte(resp.validator()), 0644); err != nil {
+			return resp, err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// partial file is invalid, remove it so the next download starts over
+		os.Remove(part)
+		os.Remove(meta)
+		return resp, newStatusError(resp.resp, nil)
+	default:
+		snippet, _ := ioutil.ReadAll(io.LimitReader(body, statusErrorBodySize))
+		return resp, newStatusError(resp.resp, snippet)
+	}
+
+	f, err := os.OpenFile(part, flag, 0644)
+	if err != nil {
+		return resp, err
+	}
+
+	total := int64(-1)
+	if resp.resp.ContentLength >= 0 {
+		total = offset + resp.resp.ContentLength
+	}
+
+	w := &progressWriter{
+		w:        f,
+		done:     offset,
+		total:    total,
+		progress: merged.DownloadProgress,
+	}
+
+	_, err = io.Copy(w, body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return resp, err
+	}
+
+	if merged.Checksum != "" {
+		if err := verifyChecksum(part, merged.Checksum); err != nil {
+			// corrupted file can't be resumed
+			os.Remove(part)
+			os.Remove(meta)
+			return resp, err
+		}
+	}
+
+	if err := os.Rename(part, path); err != nil {
+		return resp, err
+	}
+	os.Remove(meta)
+
+	return resp, nil
+}
+
+// rangeRequest send get request with unbuffered body and range headers
+func (r *Request) rangeRequest(ctx context.Context, uri string, merged Options, opts []Options, rangeHeader, ifRange string) (*Response, error) {
+	headers := make(map[string]interface{}, len(merged.Headers)+2)
+	for k, v := range merged.Headers {
+		headers[k] = v
+	}
+	if rangeHeader != "" {
+		headers["Range"] = rangeHeader
+	}
+	if ifRange != "" {
+		headers["If-Range"] = ifRange
+	}
+
+	return r.RequestWithContext(ctx, http.MethodGet, uri, append(opts, Options{
+		Headers:    headers,
+		StreamBody: true,
+	})...)
+}
+
+// validator get the response validator used by If-Range
+func (r *Response) validator() string {
+	if etag := r.GetHeaderLine("ETag"); etag != "" {
+		return etag
+	}
+
+	return r.GetHeaderLine("Last-Modified")
+}
+
+// progressWriter report written bytes to the progress callback
+type progressWriter struct {
+	w        io.Writer
+	done     int64
+	total    int64
+	progress func(done, total int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.done += int64(n)
+
+	if w.progress != nil && n > 0 {
+		w.progress(w.done, w.total)
+	}
+
+	return n, err
+}
+
+// verifyChecksum verify file digest, checksum is formatted as sha256:<hex> or md5:<hex>
+func verifyChecksum(path, checksum string) error {
+	arr := strings.SplitN(checksum, ":", 2)
+	if len(arr) != 2 {
+		return fmt.Errorf("invalid checksum %q, expect sha256:<hex> or md5:<hex>", checksum)
+	}
+
+	algorithm := strings.ToLower(arr[0])
+	expected := strings.ToLower(arr[1])
+
+	var h hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if actual != expected {
+		return &ChecksumError{
+			Algorithm: algorithm,
+			Expected:  expected,
+			Actual:    actual,
+		}
+	}
+
+	return nil
+}
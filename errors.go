@@ -0,0 +1,208 @@
+package goz
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// statusErrorBodySize max size of the body snippet kept in StatusError
+const statusErrorBodySize = 512
+
+// ErrBodyTooLarge response body exceeds Options.MaxBodySize
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// ErrTooManyRedirects redirects exceed Options.MaxRedirects
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// ErrRedirectDowngrade redirect from https to http with Options.ForbidRedirectDowngrade
+var ErrRedirectDowngrade = errors.New("redirect from https to http is forbidden")
+
+// StatusError response with non-2xx status code, returned when Options.HTTPErrors is set
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	// Body snippet of the response body
+	Body []byte
+}
+
+// Error implement error interface
+func (e *StatusError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("unexpected status: %s", e.Status)
+	}
+
+	return fmt.Sprintf("unexpected status: %s, body: %s", e.Status, e.Body)
+}
+
+// Is match a *StatusError target with the same status code,
+// a target without status code matches any status error
+func (e *StatusError) Is(target error) bool {
+	t, ok := target.(*StatusError)
+	if !ok {
+		return false
+	}
+
+	return t.StatusCode == 0 || t.StatusCode == e.StatusCode
+}
+
+// TimeoutError request timeout
+type TimeoutError struct {
+	Err error
+}
+
+// Error implement error interface
+func (e *TimeoutError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap get the underlying error
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout implement net.Error
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// Temporary implement net.Error
+func (e *TimeoutError) Temporary() bool {
+	return true
+}
+
+// TLSError tls handshake or certificate verification failed
+type TLSError struct {
+	Err error
+}
+
+// Error implement error interface
+func (e *TLSError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap get the underlying error
+func (e *TLSError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError decode response body failed
+type DecodeError struct {
+	// Format decoder used, json or xml
+	Format      string
+	ContentType string
+	Err         error
+}
+
+// Error implement error interface
+func (e *DecodeError) Error() string {
+	if e.Format == "" {
+		return fmt.Sprintf("decode response failed: %v", e.Err)
+	}
+
+	return fmt.Sprintf("decode %s response failed: %v", e.Format, e.Err)
+}
+
+// Unwrap get the underlying error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeError encode request body failed
+type EncodeError struct {
+	// Format encoder used, json, xml or body
+	Format string
+	Err    error
+}
+
+// Error implement error interface
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("encode %s body failed: %v", e.Format, e.Err)
+}
+
+// Unwrap get the underlying error
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// StreamError read event stream response failed
+type StreamError struct {
+	Err error
+}
+
+// Error implement error interface
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("decode data failed: %v", e.Err)
+}
+
+// Unwrap get the underlying error
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// newStatusError build status error from response
+func newStatusError(resp *http.Response, body []byte) *StatusError {
+	if len(body) > statusErrorBodySize {
+		body = body[:statusErrorBodySize]
+	}
+
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		Body:       body,
+	}
+}
+
+// wrapTransportError wrap timeout and tls errors returned by the http client
+func wrapTransportError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &TimeoutError{Err: err}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &TimeoutError{Err: err}
+	}
+
+	if isTLSError(err) {
+		return &TLSError{Err: err}
+	}
+
+	return err
+}
+
+// isTLSError only relies on error types of the go version in go.mod, newer
+// versions wrap the x509 errors below in tls.CertificateVerificationError
+func isTLSError(err error) bool {
+	var (
+		pinErr       *PinError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		rootsErr     x509.SystemRootsError
+		recordErr    tls.RecordHeaderError
+		opErr        *net.OpError
+	)
+
+	switch {
+	case errors.As(err, &pinErr),
+		errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr),
+		errors.As(err, &rootsErr),
+		errors.As(err, &recordErr):
+		return true
+	}
+
+	// alert sent by the server during the handshake
+	return errors.As(err, &opErr) && opErr.Op == "remote error"
+}
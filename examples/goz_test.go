@@ -1,7 +1,11 @@
 package goz
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
 
 	"github.com/idoubi/goz"
 )
@@ -12,3 +16,45 @@ func ExampleNewClient() {
 	fmt.Printf("%T", cli)
 	// Output: *goz.Request
 }
+
+func ExampleNewClient_concurrent() {
+	cli := goz.NewClient(goz.Options{
+		BaseURI:             "http://127.0.0.1:8091",
+		MaxIdleConnsPerHost: 20,
+	})
+	defer cli.CloseIdleConnections()
+
+	var wg sync.WaitGroup
+	var ok int64
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := cli.Get("/get")
+			if err != nil {
+				return
+			}
+			if body, _ := resp.GetBody(); body.String() == "http get" {
+				atomic.AddInt64(&ok, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	fmt.Println(ok)
+	// Output: 100
+}
+
+func ExampleGetCtx() {
+	resp, err := goz.GetCtx(context.Background(), "http://127.0.0.1:8091/get")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+
+	fmt.Printf("%s", body)
+	// Output: http get
+}
@@ -1,10 +1,33 @@
 package goz
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
+	"math/big"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/idoubi/goutils"
 	"github.com/idoubi/goz"
@@ -39,6 +62,75 @@ func ExampleRequest_Get() {
 	// Output: http get
 }
 
+func ExampleRequest_GetCtx() {
+	cli := goz.NewClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	resp, err := cli.GetCtx(ctx, "http://127.0.0.1:8091/get-timeout")
+	if err != nil {
+		fmt.Println(errors.Is(resp.Err(), context.DeadlineExceeded))
+	}
+	// Output: true
+}
+
+func ExampleRequest_Get_withHTTPErrors() {
+	cli := goz.NewClient(goz.Options{
+		HTTPErrors: true,
+	})
+
+	_, err := cli.Get("http://127.0.0.1:8091/get-json?status=503")
+
+	var statusErr *goz.StatusError
+	if errors.As(err, &statusErr) {
+		fmt.Println(statusErr.StatusCode, string(statusErr.Body))
+	}
+	fmt.Println(errors.Is(err, &goz.StatusError{StatusCode: 503}))
+
+	_, err = cli.Get("http://127.0.0.1:8091/get-timeout", goz.Options{
+		Timeout: 0.5,
+	})
+
+	var timeoutErr *goz.TimeoutError
+	fmt.Println(errors.As(err, &timeoutErr))
+	// Output:
+	// 503 {"code":503,"message":"Service Unavailable"}
+	// true
+	// true
+}
+
+func ExampleRequest_Get_withTLSErrors() {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "https get")
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	cli := goz.NewClient()
+
+	var tlsErr *goz.TLSError
+
+	// unknown certificate authority
+	_, err := cli.Get(ts.URL)
+	fmt.Println(errors.As(err, &tlsErr))
+
+	// handshake alert sent by the server, no client certificate
+	_, err = cli.Get(ts.URL, goz.Options{
+		InsecureSkipVerify: true,
+	})
+	fmt.Println(errors.As(err, &tlsErr))
+
+	// connection errors are not tls errors
+	_, err = cli.Get("https://127.0.0.1:1")
+	fmt.Println(err != nil, errors.As(err, &tlsErr))
+	// Output:
+	// true
+	// true
+	// true false
+}
+
 func ExampleRequest_Get_withQuery_arr() {
 	cli := goz.NewClient()
 
@@ -68,7 +160,107 @@ func ExampleRequest_Get_withQuery_str() {
 	}
 
 	fmt.Printf("%s", resp.GetRequest().URL.RawQuery)
-	// Output: key1=value1&key2=value21&key2=value22&key3=333
+	// Output: key0=value0&key1=value1&key2=value21&key2=value22&key3=333
+}
+
+func ExampleRequest_Get_withQuery_ordered() {
+	cli := goz.NewClient()
+
+	q := goz.NewOrderedValues()
+	q.Add("timestamp", "1700000000")
+	q.Add("nonce", "abc")
+	q.Add("sign", "a b")
+
+	resp, err := cli.Get("http://127.0.0.1:8091/get-with-query?sign=old&z=1&a=%7E", goz.Options{
+		Query: q,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Printf("%s", resp.GetRequest().URL.RawQuery)
+	// Output: sign=a+b&z=1&a=%7E&timestamp=1700000000&nonce=abc
+}
+
+func ExampleRequest_Get_withQuery_append() {
+	cli := goz.NewClient()
+
+	for _, mode := range []goz.QueryMode{goz.QueryReplace, goz.QueryAppend} {
+		resp, err := cli.Get("http://127.0.0.1:8091/get-with-query?a=1&page=1", goz.Options{
+			Query: map[string]interface{}{
+				"page": 2,
+			},
+			QueryMode: mode,
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		fmt.Println(resp.GetRequest().URL.RawQuery)
+	}
+	// Output:
+	// a=1&page=2
+	// a=1&page=1&page=2
+}
+
+func ExampleRequest_Get_withQuery_override() {
+	cli := goz.NewClient(goz.Options{
+		EncodeStyle: goz.EncodeDotted,
+		QueryMode:   goz.QueryAppend,
+	})
+
+	// the request switches back to the defaults
+	resp, err := cli.Get("http://127.0.0.1:8091/get-with-query?page=1", goz.Options{
+		EncodeStyle: goz.EncodeRepeat,
+		QueryMode:   goz.QueryReplace,
+		Query: map[string]interface{}{
+			"page": 2,
+			"list": []string{"x", "y"},
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Println(resp.GetRequest().URL.RawQuery)
+	// Output: page=2&list=x&list=y
+}
+
+func ExampleRequest_Get_withQuery_struct() {
+	type Page struct {
+		Number int `url:"page"`
+		Size   int `url:"size,omitempty"`
+	}
+
+	type Query struct {
+		Keyword string     `url:"q"`
+		Tags    []string   `url:"tags,comma"`
+		IDs     []int      `url:"ids,brackets"`
+		Since   time.Time  `url:"since" layout:"2006-01-02"`
+		Until   *time.Time `url:"until"`
+		Page    Page       `url:"page"`
+		Secret  string     `url:"-"`
+	}
+
+	cli := goz.NewClient()
+
+	resp, err := cli.Get("http://127.0.0.1:8091/get-with-query", goz.Options{
+		Query: Query{
+			Keyword: "goz",
+			Tags:    []string{"http", "go"},
+			IDs:     []int{1, 2},
+			Since:   time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+			Page:    Page{Number: 3},
+			Secret:  "secret",
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	query, _ := url.QueryUnescape(resp.GetRequest().URL.RawQuery)
+	fmt.Println(query)
+	// Output: q=goz&tags=http,go&ids[]=1&ids[]=2&since=2023-01-02&page[page]=3
 }
 
 func ExampleRequest_Get_withProxy() {
@@ -87,6 +279,166 @@ func ExampleRequest_Get_withProxy() {
 	// Output: Get "https://www.fbisb.com/ip.php": proxyconnect tcp: dial tcp 127.0.0.1:1087: connect: connection refused
 }
 
+func ExampleRequest_Get_withRootCA() {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "https get")
+	}))
+	defer ts.Close()
+
+	// server certificate is verified by default
+	_, err := goz.Get(ts.URL)
+	fmt.Println(strings.Contains(err.Error(), "certificate"))
+
+	ca := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: ts.Certificate().Raw,
+	})
+
+	cli := goz.NewClient(goz.Options{
+		RootCAPEM:     ca,
+		TLSMinVersion: tls.VersionTLS12,
+		ServerName:    "example.com",
+	})
+
+	resp, err := cli.Get(ts.URL)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Println(body)
+	// Output:
+	// true
+	// https get
+}
+
+func ExampleRequest_Get_withClientCertificate() {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "client:%s", r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	dir, _ := ioutil.TempDir("", "goz")
+	defer os.RemoveAll(dir)
+
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	writeClientCertificate(certFile, keyFile, "client-v1")
+
+	cli := goz.NewClient(goz.Options{
+		InsecureSkipVerify: true,
+		CertFile:           certFile,
+		KeyFile:            keyFile,
+		ReloadCertificates: true,
+	})
+
+	resp, err := cli.Get(ts.URL)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	body, _ := resp.GetBody()
+	fmt.Println(body)
+
+	// rotate certificate on disk, picked up on the next handshake
+	writeClientCertificate(certFile, keyFile, "client-v2")
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+	cli.CloseIdleConnections()
+
+	resp, err = cli.Get(ts.URL)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	body, _ = resp.GetBody()
+	fmt.Println(body)
+	// Output:
+	// client:client-v1
+	// client:client-v2
+}
+
+func ExampleRequest_Get_withPKCS12() {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "client:%s", r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	// bundle exported by openssl 3 with the default aes-256-cbc encryption
+	cli := goz.NewClient(goz.Options{
+		InsecureSkipVerify: true,
+		PKCS12File:         "testdata/client.p12",
+		PKCS12Password:     "goz",
+	})
+
+	resp, err := cli.Get(ts.URL)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	body, _ := resp.GetBody()
+	fmt.Println(body)
+
+	_, err = cli.Get(ts.URL, goz.Options{
+		PKCS12File:     "testdata/client.p12",
+		PKCS12Password: "wrong",
+	})
+	fmt.Println(err != nil)
+	// Output:
+	// client:client-p12
+	// true
+}
+
+func writeClientCertificate(certFile, keyFile, commonName string) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+}
+
+func ExampleRequest_Get_withPins() {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "https get")
+	}))
+	defer ts.Close()
+
+	cli := goz.NewClient(goz.Options{
+		RootCAPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}),
+	})
+
+	// the current pin and a backup pin
+	resp, err := cli.Get(ts.URL, goz.Options{
+		PublicKeyPins: []string{
+			"sha256/" + goz.PublicKeyPin(ts.Certificate()),
+			"sha256/YLh1dUR9y6Kja30RrAn7JKnbQG/uEtLMkBgFF2Fuihg=",
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println(resp.GetStatusCode())
+
+	resp, err = cli.Get(ts.URL, goz.Options{
+		CertificatePins: []string{"0000000000000000000000000000000000000000000000000000000000000000"},
+	})
+
+	var pinErr *goz.PinError
+	fmt.Println(errors.As(resp.Err(), &pinErr))
+	// Output:
+	// 200
+	// true
+}
+
 func ExampleRequest_Post() {
 	cli := goz.NewClient()
 
@@ -134,6 +486,29 @@ func ExampleRequest_Post_withStreamResponse() {
 	// Output: this message will response with stream
 }
 
+func ExampleRequest_Post_withRetry() {
+	cli := goz.NewClient()
+
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-retry?key=example&fail=2", goz.Options{
+		JSON: map[string]interface{}{
+			"foo": "bar",
+		},
+		Retry: &goz.Retry{
+			MaxAttempts: 3,
+			BaseDelay:   0.01,
+			Jitter:      0.5,
+			RetryAfter:  true,
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Println(resp.GetAttempts(), resp.GetStatusCode(), body)
+	// Output: 3 200 retry:{"foo":"bar"}
+}
+
 func ExampleRequest_Post_withHeaders() {
 	cli := goz.NewClient()
 
@@ -216,98 +591,1075 @@ func ExampleRequest_Post_withCookies_obj() {
 	// Output: goz.ResponseBody
 }
 
-func ExampleRequest_Post_withFormParams() {
-	cli := goz.NewClient()
-
-	resp, err := cli.Post("http://127.0.0.1:8091/post-with-form-params", goz.Options{
-		Headers: map[string]interface{}{
-			"Content-Type": "application/x-www-form-urlencoded",
-		},
-		FormParams: map[string]interface{}{
-			"key1": "value1",
-			"key2": []string{"value21", "value22"},
-			"key3": "333",
-		},
+func ExampleRequest_Get_withSession() {
+	cli := goz.NewClient(goz.Options{
+		Session: true,
 	})
+
+	resp, err := cli.Get("http://127.0.0.1:8091/get-with-set-cookies?session=abc")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	for _, cookie := range resp.GetCookies() {
+		fmt.Println(cookie.Name, cookie.Value)
+	}
+
+	resp, err = cli.Get("http://127.0.0.1:8091/get-with-cookies")
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	body, _ := resp.GetBody()
 	fmt.Println(body)
-	// Output: form params:{"key1":["value1"],"key2":["value21","value22"],"key3":["333"]}
+	// Output:
+	// session abc
+	// session:abc
 }
 
-func ExampleRequest_Post_withJSON() {
+func ExampleRequest_Get_withCookieJar() {
+	jar, _ := goz.NewCookieJar()
+
+	// Session is a client option, requests pass their own jar
 	cli := goz.NewClient()
+	if _, err := cli.Get("http://127.0.0.1:8091/get-with-set-cookies?session=jar", goz.Options{
+		CookieJar: jar,
+	}); err != nil {
+		log.Fatalln(err)
+	}
 
-	resp, err := cli.Post("http://127.0.0.1:8091/post-with-json", goz.Options{
-		Headers: goutils.MixMap{
-			"Content-Type": "application/json",
-		},
-		JSON: struct {
-			Key1 string   `json:"key1"`
-			Key2 []string `json:"key2"`
-			Key3 int      `json:"key3"`
-		}{"value1", []string{"value21", "value22"}, 333},
-	})
+	for _, options := range []goz.Options{{CookieJar: jar}, {}} {
+		resp, err := cli.Get("http://127.0.0.1:8091/get-with-cookies", options)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		body, _ := resp.GetBody()
+		fmt.Printf("%q\n", body)
+	}
+	// Output:
+	// "session:jar"
+	// "no session"
+}
+
+func ExampleRequest_SaveCookies() {
+	dir, _ := ioutil.TempDir("", "goz")
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"cookies.txt", "cookies.json"} {
+		path := filepath.Join(dir, name)
+
+		cli := goz.NewClient(goz.Options{
+			Session: true,
+		})
+		if _, err := cli.Get("http://127.0.0.1:8091/get-with-set-cookies?session=" + name); err != nil {
+			log.Fatalln(err)
+		}
+		if err := cli.SaveCookies(path); err != nil {
+			log.Fatalln(err)
+		}
+
+		// restore the session in a new client
+		cli = goz.NewClient(goz.Options{
+			Session: true,
+		})
+		if err := cli.LoadCookies(path); err != nil {
+			log.Fatalln(err)
+		}
+
+		resp, err := cli.Get("http://127.0.0.1:8091/get-with-cookies")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		body, _ := resp.GetBody()
+		fmt.Println(body)
+	}
+	// Output:
+	// session:cookies.txt
+	// session:cookies.json
+}
+
+func ExampleRequest_Get_withRedirects() {
+	cli := goz.NewClient()
+
+	uri := "http://127.0.0.1:8091/redirect?to=" + url.QueryEscape("/redirect?to=/get")
+
+	resp, err := cli.Get(uri)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
+	for _, redirect := range resp.GetRedirectHistory() {
+		fmt.Println(redirect.StatusCode, redirect.Location)
+	}
+
 	body, _ := resp.GetBody()
 	fmt.Println(body)
-	// Output: json:{"key1":"value1","key2":["value21","value22"],"key3":333}
-}
 
-func ExampleRequest_Post_withXML() {
-	cli := goz.NewClient()
+	// stop at the redirect response
+	resp, err = cli.Get(uri, goz.Options{
+		DisableRedirects: true,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println(resp.GetStatusCode(), resp.GetHeaderLine("Location"), len(resp.GetRedirectHistory()))
 
-	resp, err := cli.Post("http://127.0.0.1:8091/post-with-xml", goz.Options{
-		XML: map[string]interface{}{
-			"out_trade_no": "xxx",
-			"total_fee":    333,
+	_, err = cli.Get(uri, goz.Options{
+		MaxRedirects: 1,
+	})
+	fmt.Println(errors.Is(err, goz.ErrTooManyRedirects))
+	// Output:
+	// 302 http://127.0.0.1:8091/redirect?to=/get
+	// 302 http://127.0.0.1:8091/get
+	// http get
+	// 302 /redirect?to=/get 0
+	// true
+}
+
+func ExampleRequest_Get_withRedirectAuth() {
+	cli := goz.NewClient(goz.Options{
+		Headers: map[string]interface{}{
+			"Authorization": "Bearer token",
 		},
 	})
+
+	// hop through another host and back
+	uri := "http://127.0.0.1:8091/redirect?to=" + url.QueryEscape(
+		"http://localhost:8091/redirect?to="+url.QueryEscape("http://127.0.0.1:8091/get-with-auth"),
+	)
+
+	resp, err := cli.Get(uri)
 	if err != nil {
 		log.Fatalln(err)
 	}
-
 	body, _ := resp.GetBody()
-	fmt.Println(string(body.Read(10)))
-	// Output: xml:<xml>
+	fmt.Println(body)
+
+	resp, err = cli.Get(uri, goz.Options{
+		KeepAuthOnRedirect: true,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	body, _ = resp.GetBody()
+	fmt.Println(body)
+	// Output:
+	// auth:
+	// auth:Bearer token
 }
 
-func ExampleRequest_Post_withMultipart() {
+func ExampleRequest_Get_withRedirectDowngrade() {
+	srv := httptest.NewTLSServer(http.RedirectHandler("http://127.0.0.1:8091/get", http.StatusFound))
+	defer srv.Close()
+
 	cli := goz.NewClient(goz.Options{
-		Debug: false,
+		InsecureSkipVerify:      true,
+		ForbidRedirectDowngrade: true,
 	})
 
-	resp, err := cli.Post("http://127.0.0.1:8091/post-with-multipart", goz.Options{
-		Multipart: []goz.FormData{
-			{
-				Name:     "foo",
-				Contents: []byte("bar"),
-			},
-			{
-				Name:     "json",
-				Contents: []byte(`{"title":"title","intro":"introduction"}`),
-			},
-			{
-				Name:     "media",
-				Filepath: "./goz.png",
-			},
-		},
+	_, err := cli.Get(srv.URL)
+	fmt.Println(errors.Is(err, goz.ErrRedirectDowngrade))
+	// Output: true
+}
+
+func ExampleRequest_Get_withAuth() {
+	cli := goz.NewClient()
+
+	for _, auth := range []goz.Authenticator{
+		goz.BasicAuth("goz", "secret"),
+		goz.BearerAuth("token"),
+		goz.APIKeyAuth("Authorization", "key"),
+	} {
+		resp, err := cli.Get("http://127.0.0.1:8091/get-with-auth", goz.Options{
+			Auth: auth,
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		body, _ := resp.GetBody()
+		fmt.Println(body)
+	}
+
+	resp, err := cli.Get("http://127.0.0.1:8091/get-with-query?key1=value1", goz.Options{
+		Auth: goz.APIKeyQueryAuth("api_key", "key"),
 	})
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	body, _ := resp.GetBody()
-	fmt.Println(string(body.Read(10)))
+	fmt.Println(body)
+	// Output:
+	// auth:Basic Z296OnNlY3JldA==
+	// auth:Bearer token
+	// auth:key
+	// query:key1=value1&api_key=key
+}
+
+func ExampleRequest_Get_withDigestAuth() {
+	cli := goz.NewClient(goz.Options{
+		Auth: goz.DigestAuth("goz", "secret"),
+	})
+
+	// the first request answers the 401 challenge,
+	// the second reuses the cached nonce
+	for i := 0; i < 2; i++ {
+		resp, err := cli.Get("http://127.0.0.1:8091/digest-auth?page=1")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		body, _ := resp.GetBody()
+		fmt.Println(resp.GetStatusCode(), body)
+	}
+
+	resp, err := cli.Get("http://127.0.0.1:8091/digest-auth", goz.Options{
+		Auth: goz.DigestAuth("goz", "wrong"),
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println(resp.GetStatusCode())
+	// Output:
+	// 200 digest auth:goz algorithm:SHA-256 nc:00000001
+	// 200 digest auth:goz algorithm:SHA-256 nc:00000002
+	// 401
+}
+
+func ExampleNewTokenSource() {
+	source := goz.NewTokenSource(goz.OAuth2Config{
+		TokenURL:     "http://127.0.0.1:8091/oauth2/token",
+		ClientID:     "goz",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+
+	cli := goz.NewClient(goz.Options{
+		Auth: source,
+	})
+
+	// concurrent requests share a single token
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			resp, err := cli.Get("http://127.0.0.1:8091/oauth2/resource")
+			if err != nil {
+				log.Fatalln(err)
+			}
+			body, _ := resp.GetBody()
+			tokens[i] = body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	same := true
+	for _, token := range tokens {
+		same = same && token == tokens[0]
+	}
+	fmt.Println(same)
+
+	// revoked token is replaced after the 401 response
+	goz.Post("http://127.0.0.1:8091/oauth2/revoke", goz.Options{
+		FormParams: map[string]interface{}{
+			"token": tokens[0],
+		},
+	})
+
+	resp, err := cli.Get("http://127.0.0.1:8091/oauth2/resource")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	body, _ := resp.GetBody()
+	fmt.Println(resp.GetStatusCode(), body.String() != tokens[0])
+	// Output:
+	// true
+	// 200 true
+}
+
+func ExampleNewTokenSource_withRefreshToken() {
+	tok, err := goz.NewTokenSource(goz.OAuth2Config{
+		TokenURL:     "http://127.0.0.1:8091/oauth2/token",
+		ClientID:     "goz",
+		ClientSecret: "secret",
+	}).Token(context.Background())
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	source := goz.NewTokenSource(goz.OAuth2Config{
+		TokenURL:     "http://127.0.0.1:8091/oauth2/token",
+		ClientID:     "goz",
+		ClientSecret: "secret",
+		BasicAuth:    true,
+		RefreshToken: tok.RefreshToken,
+	})
+
+	refreshed, err := source.Token(context.Background())
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println(refreshed.AccessToken != tok.AccessToken, refreshed.Expiry.After(time.Now()))
+
+	_, err = goz.NewTokenSource(goz.OAuth2Config{
+		TokenURL:     "http://127.0.0.1:8091/oauth2/token",
+		ClientID:     "goz",
+		ClientSecret: "secret",
+		RefreshToken: "invalid",
+	}).Token(context.Background())
+
+	var oauthErr *goz.OAuth2Error
+	fmt.Println(errors.As(err, &oauthErr), err)
+	// Output:
+	// true true
+	// true oauth2: 400 invalid_grant
+}
+
+func ExampleSigV4() {
+	cli := goz.NewClient()
+
+	for _, service := range []string{"execute-api", "s3"} {
+		resp, err := cli.Put("http://127.0.0.1:8091/sigv4/bucket/goz.txt", goz.Options{
+			Query: map[string]interface{}{
+				"b":      "2",
+				"a":      "1 2",
+				"a1":     "3",
+				"a-b":    "4",
+				"prefix": "dir/",
+			},
+			Body: "goz upload",
+			Signer: &goz.SigV4{
+				AccessKeyID:     "AKIDEXAMPLE",
+				SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+				Region:          "us-east-1",
+				Service:         service,
+			},
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		body, _ := resp.GetBody()
+		fmt.Println(resp.GetStatusCode(), body)
+	}
+	// Output:
+	// 200 sigv4 ok:goz upload
+	// 200 sigv4 ok:goz upload
+}
+
+func ExampleSigV4_withFile() {
+	f, _ := ioutil.TempFile("", "goz")
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	f.WriteString("goz upload")
+	f.Seek(0, io.SeekStart)
+
+	signer := &goz.SigV4{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "s3",
+	}
+
+	// hashing the body for the signature doesn't consume it,
+	// for files and for plain seekers
+	cli := goz.NewClient()
+	for _, body := range []io.ReadSeeker{f, struct{ io.ReadSeeker }{f}} {
+		resp, err := cli.Put("http://127.0.0.1:8091/sigv4/bucket/goz.txt", goz.Options{
+			Body:   body,
+			Signer: signer,
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		respBody, _ := resp.GetBody()
+		fmt.Println(resp.GetStatusCode(), respBody)
+	}
+	// Output:
+	// 200 sigv4 ok:goz upload
+	// 200 sigv4 ok:goz upload
+}
+
+func ExampleSigV4_SignAt() {
+	signer := &goz.SigV4{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "service",
+	}
+	t, _ := time.Parse("20060102T150405Z", "20150830T123600Z")
+
+	// requests of the aws signature version 4 test suite
+	tests := []struct {
+		method string
+		uri    string
+		body   string
+	}{
+		{"GET", "https://example.amazonaws.com/", ""},
+		{"GET", "https://example.amazonaws.com/?Param2=value2&Param1=value1", ""},
+		{"GET", "https://example.amazonaws.com/?Param1=value2&Param1=value1", ""},
+		{"GET", "https://example.amazonaws.com/?ሴ=bar", ""},
+		{"POST", "https://example.amazonaws.com/", ""},
+		{"POST", "https://example.amazonaws.com/", "Param1=value1"},
+	}
+
+	for _, tt := range tests {
+		var body io.Reader
+		if tt.body != "" {
+			body = strings.NewReader(tt.body)
+		}
+		req, _ := http.NewRequest(tt.method, tt.uri, body)
+		if tt.body != "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+
+		if err := signer.SignAt(req, t); err != nil {
+			log.Fatalln(err)
+		}
+
+		auth := req.Header.Get("Authorization")
+		fmt.Println(auth[strings.LastIndex(auth, "=")+1:])
+	}
+	// Output:
+	// 5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31
+	// b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500
+	// 5772eed61e12b33fae39ee5e7012498b51d56abc0abb7c60486157bd471c4694
+	// 2cdec8eed098649ff3a119c94853b13c643bcf08f8b0a1d91e12c9027818dd04
+	// 5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b
+	// ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a
+}
+
+func ExampleHMACSigner() {
+	cli := goz.NewClient(goz.Options{
+		Signer: &goz.HMACSigner{
+			KeyID:   "AKIDEXAMPLE",
+			Secret:  "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			Headers: []string{"Content-Type"},
+		},
+	})
+
+	resp, err := cli.Post("http://127.0.0.1:8091/hmac?key2=value2&key1=value1", goz.Options{
+		JSON: map[string]interface{}{
+			"key1": "value1",
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Println(resp.GetStatusCode(), body)
+
+	// invalid signature is rejected
+	resp, err = cli.Post("http://127.0.0.1:8091/hmac", goz.Options{
+		Body: "goz",
+		Signer: goz.SignerFunc(func(req *http.Request) error {
+			req.Header.Set("Authorization", "HMAC-SHA256 KeyId=AKIDEXAMPLE, SignedHeaders=, Signature=invalid")
+			return nil
+		}),
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println(resp.GetStatusCode())
+	// Output:
+	// 200 hmac ok:{"key1":"value1"}
+	// 403
+}
+
+func ExampleHMACSigner_withMultipart() {
+	cli := goz.NewClient()
+
+	// hashing the body for the signature doesn't report upload progress
+	var completed int
+	resp, err := cli.Post("http://127.0.0.1:8091/hmac", goz.Options{
+		Multipart: []goz.FormData{
+			{
+				Name:     "content",
+				Contents: []byte("goz upload"),
+			},
+		},
+		UploadProgress: func(done, total int64) {
+			if done == total {
+				completed++
+			}
+		},
+		Signer: &goz.HMACSigner{
+			KeyID:  "AKIDEXAMPLE",
+			Secret: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Println(resp.GetStatusCode(), completed)
+	// Output: 200 1
+}
+
+func ExampleResponse_TraceInfo() {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "goz trace")
+	}))
+	defer srv.Close()
+
+	cli := goz.NewClient(goz.Options{
+		InsecureSkipVerify: true,
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := cli.Get(srv.URL)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		info := resp.TraceInfo()
+		fmt.Println(info.ConnReused, info.Connect > 0, info.TLSHandshake > 0, info.FirstByte > 0, info.Total >= info.FirstByte)
+	}
+	// Output:
+	// false true true true true
+	// true false false true true
+}
+
+func ExampleRequest_Get_withPhaseTimeouts() {
+	cli := goz.NewClient(goz.Options{
+		DialTimeout:         5,
+		TLSHandshakeTimeout: 5,
+	})
+
+	resp, err := cli.Get("http://127.0.0.1:8091/get-timeout", goz.Options{
+		ResponseHeaderTimeout: 0.2,
+	})
+
+	var timeoutErr *goz.TimeoutError
+	fmt.Println(errors.As(err, &timeoutErr), resp.IsTimeout())
+	// Output: true true
+}
+
+func ExampleRequest_Post_withFormParams() {
+	cli := goz.NewClient()
+
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-form-params", goz.Options{
+		Headers: map[string]interface{}{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		FormParams: map[string]interface{}{
+			"key1": "value1",
+			"key2": []string{"value21", "value22"},
+			"key3": "333",
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Println(body)
+	// Output: form params:{"key1":["value1"],"key2":["value21","value22"],"key3":["333"]}
+}
+
+func ExampleRequest_Post_withFormParams_brackets() {
+	cli := goz.NewClient()
+
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-form-params", goz.Options{
+		EncodeStyle: goz.EncodeBrackets,
+		FormParams: map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": map[string]interface{}{
+					"c": 1,
+				},
+			},
+			"list": []string{"x", "y"},
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Println(body)
+	// Output: form params:{"a[b][c]":["1"],"list[]":["x","y"]}
+}
+
+func ExampleRequest_Get_withQuery_dotted() {
+	cli := goz.NewClient()
+
+	resp, err := cli.Get("http://127.0.0.1:8091/get-with-query", goz.Options{
+		EncodeStyle: goz.EncodeDotted,
+		Query: map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": "1",
+			},
+			"list": []string{"x", "y"},
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Println(resp.GetRequest().URL.RawQuery)
+	// Output: a.b=1&list.0=x&list.1=y
+}
+
+func ExampleRequest_Post_withJSON() {
+	cli := goz.NewClient()
+
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-json", goz.Options{
+		Headers: goutils.MixMap{
+			"Content-Type": "application/json",
+		},
+		JSON: struct {
+			Key1 string   `json:"key1"`
+			Key2 []string `json:"key2"`
+			Key3 int      `json:"key3"`
+		}{"value1", []string{"value21", "value22"}, 333},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Println(body)
+	// Output: json:{"key1":"value1","key2":["value21","value22"],"key3":333}
+}
+
+func ExampleRequest_Post_withXML() {
+	cli := goz.NewClient()
+
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-xml", goz.Options{
+		XML: map[string]interface{}{
+			"out_trade_no": "xxx",
+			"total_fee":    333,
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Println(string(body.Read(10)))
+	// Output: xml:<xml>
+}
+
+func ExampleRequest_Post_withBody() {
+	cli := goz.NewClient()
+
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-body", goz.Options{
+		Headers: map[string]interface{}{
+			"Content-Type": "application/x-protobuf",
+		},
+		Body: []byte{0x08, 0x96, 0x01},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Printf("%q\n", body)
+
+	// reader with explicit content length
+	resp, err = cli.Post("http://127.0.0.1:8091/post-with-body", goz.Options{
+		Headers: map[string]interface{}{
+			"Content-Type":   "text/plain",
+			"Content-Length": 5,
+		},
+		Body: io.LimitReader(strings.NewReader("hello world"), 5),
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ = resp.GetBody()
+	fmt.Println(body)
+	// Output:
+	// "body:application/x-protobuf,3,\b\x96\x01"
+	// body:text/plain,5,hello
+}
+
+func ExampleRequest_Post_withXML_strMap() {
+	cli := goz.NewClient()
+
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-xml", goz.Options{
+		XML: map[string]string{
+			"out_trade_no": "xxx",
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Println(body)
+	// Output:
+	// xml:<xml>
+	//   <out_trade_no>xxx</out_trade_no>
+	// </xml>
+}
+
+func ExampleRequest_Post_withEncodeError() {
+	cli := goz.NewClient()
+
+	_, err := cli.Post("http://127.0.0.1:8091/post-with-json", goz.Options{
+		JSON: map[string]interface{}{
+			"ch": make(chan int),
+		},
+	})
+
+	var encodeErr *goz.EncodeError
+	fmt.Println(errors.As(err, &encodeErr), encodeErr.Format)
+	// Output: true json
+}
+
+func ExampleRequest_Post_withMultipart() {
+	cli := goz.NewClient(goz.Options{
+		Debug: false,
+	})
+
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-multipart", goz.Options{
+		Multipart: []goz.FormData{
+			{
+				Name:     "foo",
+				Contents: []byte("bar"),
+			},
+			{
+				Name:     "json",
+				Contents: []byte(`{"title":"title","intro":"introduction"}`),
+			},
+			{
+				Name:     "media",
+				Filepath: "./goz.png",
+			},
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Println(string(body.Read(10)))
 	// Output: body:
 }
 
+func ExampleRequest_Use() {
+	cli := goz.NewClient()
+
+	// sign request
+	cli.Use(func(next goz.Handler) goz.Handler {
+		return func(req *http.Request) (*goz.Response, error) {
+			req.Header.Set("X-Signature", req.Method+" "+req.URL.Path)
+			return next(req)
+		}
+	})
+	// log response status
+	cli.Use(func(next goz.Handler) goz.Handler {
+		return func(req *http.Request) (*goz.Response, error) {
+			resp, err := next(req)
+			if err == nil {
+				fmt.Println(req.URL.Path, resp.GetStatusCode())
+			}
+			return resp, err
+		}
+	})
+
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-headers")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Println(resp.GetRequest().Header.Get("X-Signature"))
+	// Output:
+	// /post-with-headers 200
+	// POST /post-with-headers
+}
+
+func ExampleRequest_Download() {
+	content := strings.Repeat("goz download\n", 1000)
+	sum := sha256.Sum256([]byte(content))
+
+	dir, _ := ioutil.TempDir("", "goz")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "goz.txt")
+
+	// partial file and validator left by an interrupted download
+	ioutil.WriteFile(path+".part", []byte(content[:100]), 0644)
+	ioutil.WriteFile(path+".part.meta", []byte("Sun, 01 Jan 2023 00:00:00 GMT"), 0644)
+
+	cli := goz.NewClient()
+
+	resp, err := cli.Download("http://127.0.0.1:8091/download", path, goz.Options{
+		DownloadProgress: func(done, total int64) {
+			if done == total {
+				fmt.Println("progress:", done, total)
+			}
+		},
+		Checksum: "sha256:" + hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fi, _ := os.Stat(path)
+	fmt.Println(resp.GetStatusCode(), fi.Size())
+	// Output:
+	// progress: 13000 13000
+	// 206 13000
+}
+
+func ExampleRequest_Download_withoutValidator() {
+	content := strings.Repeat("goz download\n", 1000)
+
+	dir, _ := ioutil.TempDir("", "goz")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "goz.txt")
+
+	// partial file without validator can't be trusted, the download starts over
+	ioutil.WriteFile(path+".part", []byte("stale content"), 0644)
+
+	cli := goz.NewClient()
+	resp, err := cli.Download("http://127.0.0.1:8091/download", path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	fmt.Println(resp.GetStatusCode(), string(data) == content)
+	// Output: 200 true
+}
+
+func ExampleRequest_Download_withWrongRange() {
+	content := strings.Repeat("goz download\n", 1000)
+
+	dir, _ := ioutil.TempDir("", "goz")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "goz.txt")
+
+	ioutil.WriteFile(path+".part", []byte(content[:100]), 0644)
+	ioutil.WriteFile(path+".part.meta", []byte("Sun, 01 Jan 2023 00:00:00 GMT"), 0644)
+
+	// the server answers from the first byte, the range isn't appended
+	cli := goz.NewClient()
+	resp, err := cli.Download("http://127.0.0.1:8091/download-wrong-range", path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	fmt.Println(resp.GetStatusCode(), string(data) == content)
+	// Output: 200 true
+}
+
+func ExampleRequest_Download_withTimeout() {
+	dir, _ := ioutil.TempDir("", "goz")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "slow.txt")
+
+	cli := goz.NewClient(goz.Options{
+		Timeout: 0.5,
+	})
+
+	// the body takes longer than Timeout
+	_, err := cli.Download("http://127.0.0.1:8091/get-slow-body", path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fi, _ := os.Stat(path)
+	fmt.Println(fi.Size())
+	// Output: 55
+}
+
+func ExampleRequest_Download_withSegments() {
+	content := strings.Repeat("goz download\n", 1000)
+	sum := md5.Sum([]byte(content))
+
+	dir, _ := ioutil.TempDir("", "goz")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "goz.txt")
+
+	cli := goz.NewClient()
+
+	resp, err := cli.Download("http://127.0.0.1:8091/download", path, goz.Options{
+		DownloadSegments: 4,
+		DownloadProgress: func(done, total int64) {
+			if done == total {
+				fmt.Println("progress:", done, total)
+			}
+		},
+		Checksum: "md5:" + hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// the response describes the whole file
+	b, _ := ioutil.ReadFile(path)
+	fmt.Println(resp.GetStatusCode(), resp.GetHeaderLine("Content-Length"), string(b) == content)
+	// Output:
+	// progress: 13000 13000
+	// 200 13000 true
+}
+
+func ExampleRequest_Download_withSegmentsFallback() {
+	dir, _ := ioutil.TempDir("", "goz")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "goz.txt")
+
+	cli := goz.NewClient()
+
+	// ranges not supported, downloaded in a single stream
+	resp, err := cli.Download("http://127.0.0.1:8091/download-no-range", path, goz.Options{
+		DownloadSegments: 4,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fi, _ := os.Stat(path)
+	fmt.Println(resp.GetStatusCode(), resp.GetHeaderLine("Accept-Ranges") == "", fi.Size())
+	// Output: 200 true 13000
+}
+
+func ExampleRequest_Download_withSegmentsRetry() {
+	dir, _ := ioutil.TempDir("", "goz")
+	defer os.RemoveAll(dir)
+
+	key := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	cli := goz.NewClient()
+
+	// the failing segment is sent MaxAttempts times with backoff in between
+	_, err := cli.Download("http://127.0.0.1:8091/download-flaky?key="+key, filepath.Join(dir, "goz.txt"), goz.Options{
+		DownloadSegments: 2,
+		Retry: &goz.Retry{
+			MaxAttempts: 3,
+			BaseDelay:   0.05,
+		},
+	})
+
+	var statusErr *goz.StatusError
+	fmt.Println(errors.As(err, &statusErr))
+
+	resp, _ := cli.Get("http://127.0.0.1:8091/download-flaky?count=1&key=" + key)
+	body, _ := resp.GetBody()
+	fmt.Println(body)
+	// Output:
+	// true
+	// requests:5
+}
+
+func ExampleRequest_Post_withMultipartReader() {
+	cli := goz.NewClient()
+
+	var sent, total int64
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-multipart", goz.Options{
+		Multipart: []goz.FormData{
+			{
+				Name:   "reader",
+				Reader: strings.NewReader("streamed from reader"),
+			},
+			{
+				Name:     "media",
+				Filepath: "./goz.png",
+			},
+		},
+		UploadProgress: func(done, size int64) {
+			sent, total = done, size
+		},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Println(resp.GetRequest().ContentLength == total, sent == total)
+	// Output: true true
+}
+
+// kvCodec encode map[string]string as key=value lines
+type kvCodec struct{}
+
+func (kvCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(map[string]string)
+	if !ok {
+		return nil, fmt.Errorf("kv codec can't marshal %T", v)
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", k, m[k])
+	}
+
+	return []byte(b.String()), nil
+}
+
+func (kvCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(*map[string]string)
+	if !ok {
+		return fmt.Errorf("kv codec can't unmarshal into %T", v)
+	}
+
+	*m = map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		arr := strings.SplitN(line, "=", 2)
+		if len(arr) == 2 {
+			(*m)[arr[0]] = arr[1]
+		}
+	}
+
+	return nil
+}
+
+func (kvCodec) ContentType() string {
+	return "application/x-kv"
+}
+
+func ExampleRequest_RegisterCodec() {
+	cli := goz.NewClient()
+	cli.RegisterCodec(kvCodec{})
+
+	var result map[string]string
+	resp, err := cli.Post("http://127.0.0.1:8091/post-with-codec", goz.Options{
+		Headers: map[string]interface{}{
+			"Content-Type": "application/x-kv",
+		},
+		Payload: map[string]string{
+			"foo": "bar",
+			"baz": "qux",
+		},
+		Result: &result,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, _ := resp.GetBody()
+	fmt.Printf("%s", body)
+	fmt.Println(result["foo"], result["baz"])
+	// Output:
+	// baz=qux
+	// foo=bar
+	// bar qux
+}
+
 func ExampleRequest_Put() {
 	cli := goz.NewClient()
 
@@ -1,8 +1,12 @@
 package goz
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
+	"os"
 
 	"github.com/idoubi/goz"
 )
@@ -39,6 +43,122 @@ func ExampleResponse_GetParsedBody() {
 	// Output: *gjson.Result,10001,参数错误
 }
 
+func ExampleResponse_Decode() {
+	cli := goz.NewClient()
+	resp, err := cli.Get("http://127.0.0.1:8091/get-json")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := resp.Decode(&result); err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Println(result.Code, result.Message)
+	// Output: 200 OK
+}
+
+func ExampleResponse_Decode_withResult() {
+	type Result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+
+	cli := goz.NewClient(goz.Options{
+		DisallowUnknownFields: true,
+	})
+
+	var result, errorResult Result
+	resp, err := cli.Get("http://127.0.0.1:8091/get-json?status=404", goz.Options{
+		Result:      &result,
+		ErrorResult: &errorResult,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Println(resp.GetStatusCode(), result.Code, errorResult.Message)
+	// Output: 404 0 Not Found
+}
+
+func ExampleResponse_Decode_withNoContent() {
+	var result struct {
+		Code int `json:"code"`
+	}
+
+	// responses without body leave Result untouched
+	cli := goz.NewClient()
+	resp, err := cli.Get("http://127.0.0.1:8091/get-json?status=204", goz.Options{
+		Result: &result,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Println(resp.GetStatusCode(), result.Code)
+	// Output: 204 0
+}
+
+func ExampleResponse_BodyReader() {
+	cli := goz.NewClient()
+	resp, err := cli.Get("http://127.0.0.1:8091/get", goz.Options{
+		StreamBody: true,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body := resp.BodyReader()
+	defer body.Close()
+
+	io.Copy(os.Stdout, body)
+	// Output: http get
+}
+
+func ExampleResponse_BodyReader_withTimeout() {
+	cli := goz.NewClient(goz.Options{
+		Timeout: 0.5,
+	})
+
+	// Timeout limits the wait for the headers, the body takes longer
+	resp, err := cli.Get("http://127.0.0.1:8091/get-slow-body", goz.Options{
+		StreamBody: true,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body := resp.BodyReader()
+	defer body.Close()
+
+	n, err := io.Copy(ioutil.Discard, body)
+	fmt.Println(n, err)
+
+	_, err = cli.Get("http://127.0.0.1:8091/get-timeout", goz.Options{
+		StreamBody: true,
+	})
+
+	var timeoutErr *goz.TimeoutError
+	fmt.Println(errors.As(err, &timeoutErr))
+	// Output:
+	// 55 <nil>
+	// true
+}
+
+func ExampleResponse_GetBody_withMaxBodySize() {
+	cli := goz.NewClient()
+	_, err := cli.Get("http://127.0.0.1:8091/get", goz.Options{
+		MaxBodySize: 4,
+	})
+
+	fmt.Println(errors.Is(err, goz.ErrBodyTooLarge))
+	// Output: true
+}
+
 func ExampleResponseBody_Read() {
 	cli := goz.NewClient()
 	resp, err := cli.Get("http://127.0.0.1:8091/get")
@@ -1,27 +1,90 @@
 package main
 
 import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
+// downloadContent content served by download handler
+var downloadContent = strings.Repeat("goz download\n", 1000)
+
+var (
+	retriesMu sync.Mutex
+	retries   = map[string]int{}
+)
+
+// digest auth credentials and the last nonce count of issued nonces
+const (
+	digestUser     = "goz"
+	digestPassword = "secret"
+	digestRealm    = "goz@example.com"
+)
+
+var (
+	noncesMu sync.Mutex
+	nonces   = map[string]int64{}
+)
+
+// request signing credentials
+const (
+	signAccessKey = "AKIDEXAMPLE"
+	signSecret    = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+)
+
+// oauth2 issued access and refresh tokens
+var (
+	tokensMu      sync.Mutex
+	tokenID       int
+	accessTokens  = map[string]bool{}
+	refreshTokens = map[string]bool{}
+)
+
 func main() {
 	http.HandleFunc("/get", get)
 	http.HandleFunc("/get-response-json", getResponseJSON)
+	http.HandleFunc("/get-json", getJSON)
 	http.HandleFunc("/get-timeout", getTimeout)
+	http.HandleFunc("/get-slow-body", getSlowBody)
 	http.HandleFunc("/get-with-query", getWithQuery)
+	http.HandleFunc("/get-with-set-cookies", getWithSetCookies)
+	http.HandleFunc("/get-with-cookies", getWithCookies)
+	http.HandleFunc("/get-with-auth", getWithAuth)
+	http.HandleFunc("/redirect", redirect)
+	http.HandleFunc("/digest-auth", digestAuth)
+	http.HandleFunc("/sigv4/", sigV4)
+	http.HandleFunc("/hmac", hmacSigned)
+	http.HandleFunc("/oauth2/token", oauth2Token)
+	http.HandleFunc("/oauth2/resource", oauth2Resource)
+	http.HandleFunc("/oauth2/revoke", oauth2Revoke)
 	http.HandleFunc("/post", post)
 	http.HandleFunc("/post-with-headers", postWithHeaders)
 	http.HandleFunc("/post-with-cookies", postWithCookies)
 	http.HandleFunc("/post-with-form-params", postWithFormParams)
 	http.HandleFunc("/post-with-json", postWithJSON)
 	http.HandleFunc("/post-with-xml", postWithXML)
+	http.HandleFunc("/post-with-body", postWithBody)
+	http.HandleFunc("/post-with-codec", postWithCodec)
 	http.HandleFunc("/post-with-multipart", postWithMultipart)
 	http.HandleFunc("/post-with-stream-response", postWithStreamResponse)
+	http.HandleFunc("/post-with-retry", postWithRetry)
+	http.HandleFunc("/download", download)
+	http.HandleFunc("/download-no-range", downloadNoRange)
+	http.HandleFunc("/download-flaky", downloadFlaky)
+	http.HandleFunc("/download-wrong-range", downloadWrongRange)
 	http.HandleFunc("/put", put)
 	http.HandleFunc("/patch", patch)
 	http.HandleFunc("/delete", delete)
@@ -47,16 +110,347 @@ func getResponseJSON(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, string(b))
 }
 
+func getJSON(w http.ResponseWriter, r *http.Request) {
+	status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+
+	m := map[string]interface{}{
+		"code":    status,
+		"message": http.StatusText(status),
+	}
+	b, _ := json.Marshal(m)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
 func getTimeout(w http.ResponseWriter, r *http.Request) {
 	time.Sleep(time.Duration(1) * time.Second)
 	fmt.Fprintf(w, "http get timeout")
 }
 
+func getSlowBody(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(w, "goz slow %d\n", i)
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
 func getWithQuery(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.RawQuery
 	fmt.Fprintf(w, "query:%s", q)
 }
 
+func getWithSetCookies(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   "session",
+		Value:  r.URL.Query().Get("session"),
+		Path:   "/",
+		MaxAge: 3600,
+	})
+
+	fmt.Fprintf(w, "cookies set")
+}
+
+func getWithCookies(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie("session")
+	if err != nil {
+		fmt.Fprintf(w, "no session")
+		return
+	}
+
+	fmt.Fprintf(w, "session:%s", cookie.Value)
+}
+
+func getWithAuth(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "auth:%s", r.Header.Get("Authorization"))
+}
+
+func redirect(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+}
+
+func digestAuth(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Digest ") {
+		digestChallenge(w)
+		return
+	}
+
+	params := map[string]string{}
+	for _, kv := range strings.Split(auth[7:], ",") {
+		arr := strings.SplitN(strings.TrimSpace(kv), "=", 2)
+		if len(arr) == 2 {
+			params[arr[0]] = strings.Trim(arr[1], `"`)
+		}
+	}
+
+	h := func(s string) string {
+		if params["algorithm"] == "SHA-256" {
+			sum := sha256.Sum256([]byte(s))
+			return hex.EncodeToString(sum[:])
+		}
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	// nonce count must increase
+	nc, _ := strconv.ParseInt(params["nc"], 16, 64)
+	noncesMu.Lock()
+	last, ok := nonces[params["nonce"]]
+	if ok && nc > last {
+		nonces[params["nonce"]] = nc
+	}
+	noncesMu.Unlock()
+	if !ok || nc <= last {
+		digestChallenge(w)
+		return
+	}
+
+	ha1 := h(digestUser + ":" + digestRealm + ":" + digestPassword)
+	ha2 := h(r.Method + ":" + params["uri"])
+	expected := h(ha1 + ":" + params["nonce"] + ":" + params["nc"] + ":" + params["cnonce"] + ":" + params["qop"] + ":" + ha2)
+	if params["username"] != digestUser || params["uri"] != r.URL.RequestURI() || params["response"] != expected {
+		digestChallenge(w)
+		return
+	}
+
+	fmt.Fprintf(w, "digest auth:%s algorithm:%s nc:%s", params["username"], params["algorithm"], params["nc"])
+}
+
+func digestChallenge(w http.ResponseWriter) {
+	b := make([]byte, 16)
+	rand.Read(b)
+	nonce := hex.EncodeToString(b)
+
+	noncesMu.Lock()
+	nonces[nonce] = 0
+	noncesMu.Unlock()
+
+	for _, algorithm := range []string{"MD5", "SHA-256"} {
+		w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth", algorithm=%s, nonce="%s", opaque="goz"`, digestRealm, algorithm, nonce))
+	}
+	w.WriteHeader(http.StatusUnauthorized)
+	fmt.Fprintf(w, "unauthorized")
+}
+
+func sigV4(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	for _, kv := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 "), ", ") {
+		arr := strings.SplitN(kv, "=", 2)
+		if len(arr) == 2 {
+			params[arr[0]] = arr[1]
+		}
+	}
+
+	credential := strings.SplitN(params["Credential"], "/", 2)
+	if len(credential) != 2 || credential[0] != signAccessKey {
+		http.Error(w, "invalid credential", http.StatusForbidden)
+		return
+	}
+	scope := strings.Split(credential[1], "/")
+	if len(scope) != 4 {
+		http.Error(w, "invalid scope", http.StatusForbidden)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	payloadHash := sha256Hex(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != "" && r.Header.Get("X-Amz-Content-Sha256") != payloadHash {
+		http.Error(w, "invalid payload hash", http.StatusForbidden)
+		return
+	}
+
+	var headers []string
+	for _, name := range strings.Split(params["SignedHeaders"], ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers = append(headers, name+":"+strings.TrimSpace(value))
+	}
+
+	path := r.URL.EscapedPath()
+	if scope[2] != "s3" {
+		path = strings.Replace(url.PathEscape(path), "%2F", "/", -1)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		path,
+		sortedQuery(r.URL.Query()),
+		strings.Join(headers, "\n") + "\n",
+		params["SignedHeaders"],
+		payloadHash,
+	}, "\n")
+
+	key := []byte("AWS4" + signSecret)
+	for _, part := range scope {
+		key = hmacSum(key, part)
+	}
+	stringToSign := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" + credential[1] + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	if hex.EncodeToString(hmacSum(key, stringToSign)) != params["Signature"] {
+		http.Error(w, "signature mismatch", http.StatusForbidden)
+		return
+	}
+
+	fmt.Fprintf(w, "sigv4 ok:%s", body)
+}
+
+func hmacSigned(w http.ResponseWriter, r *http.Request) {
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), "HMAC-SHA256 ")
+	params := map[string]string{}
+	for _, kv := range strings.Split(auth, ", ") {
+		arr := strings.SplitN(kv, "=", 2)
+		if len(arr) == 2 {
+			params[arr[0]] = arr[1]
+		}
+	}
+
+	timestamp, _ := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+	if params["KeyId"] != signAccessKey || time.Since(time.Unix(timestamp, 0)) > 5*time.Minute {
+		http.Error(w, "invalid key or timestamp", http.StatusForbidden)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	lines := []string{r.Method, r.URL.EscapedPath(), sortedQuery(r.URL.Query()), r.Header.Get("X-Timestamp")}
+	if params["SignedHeaders"] != "" {
+		for _, name := range strings.Split(params["SignedHeaders"], ";") {
+			lines = append(lines, name+":"+r.Header.Get(name))
+		}
+	}
+	lines = append(lines, sha256Hex(body))
+
+	if hex.EncodeToString(hmacSum([]byte(signSecret), strings.Join(lines, "\n"))) != params["Signature"] {
+		http.Error(w, "signature mismatch", http.StatusForbidden)
+		return
+	}
+
+	fmt.Fprintf(w, "hmac ok:%s", body)
+}
+
+// sortedQuery query sorted by parameter name then value
+func sortedQuery(q url.Values) string {
+	escape := func(s string) string {
+		return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+	}
+
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return escape(keys[i]) < escape(keys[j])
+	})
+
+	var pairs []string
+	for _, k := range keys {
+		values := make([]string, 0, len(q[k]))
+		for _, v := range q[k] {
+			values = append(values, escape(v))
+		}
+		sort.Strings(values)
+
+		for _, v := range values {
+			pairs = append(pairs, escape(k)+"="+v)
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSum(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func oauth2Token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if clientID != "goz" || clientSecret != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, `{"error":"invalid_client"}`)
+		return
+	}
+
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	switch r.PostFormValue("grant_type") {
+	case "client_credentials":
+	case "refresh_token":
+		if !refreshTokens[r.PostFormValue("refresh_token")] {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error":"invalid_grant"}`)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"error":"unsupported_grant_type"}`)
+		return
+	}
+
+	tokenID++
+	accessToken := fmt.Sprintf("access-%d", tokenID)
+	refreshToken := fmt.Sprintf("refresh-%d", tokenID)
+	accessTokens[accessToken] = true
+	refreshTokens[refreshToken] = true
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  accessToken,
+		"token_type":    "bearer",
+		"refresh_token": refreshToken,
+		"expires_in":    3600,
+		"scope":         r.PostFormValue("scope"),
+	})
+}
+
+func oauth2Resource(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	tokensMu.Lock()
+	ok := accessTokens[token]
+	tokensMu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, "invalid token")
+		return
+	}
+
+	fmt.Fprintf(w, "%s", token)
+}
+
+func oauth2Revoke(w http.ResponseWriter, r *http.Request) {
+	tokensMu.Lock()
+	accessTokens[r.PostFormValue("token")] = false
+	tokensMu.Unlock()
+
+	fmt.Fprintf(w, "revoked")
+}
+
 func post(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		fmt.Fprintf(w, "need post")
@@ -122,6 +516,30 @@ func postWithXML(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "xml:%s", xml)
 }
 
+func postWithBody(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		fmt.Fprintf(w, "need post")
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	fmt.Fprintf(w, "body:%s,%d,%s", r.Header.Get("Content-Type"), r.ContentLength, body)
+}
+
+func postWithCodec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		fmt.Fprintf(w, "need post")
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	// echo body with the request content type
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	w.Write(body)
+}
+
 func postWithMultipart(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		fmt.Fprintf(w, "need post")
@@ -192,6 +610,79 @@ func postWithStreamResponse(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func postWithRetry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		fmt.Fprintf(w, "need post")
+		return
+	}
+
+	key := r.URL.Query().Get("key")
+	fail, _ := strconv.Atoi(r.URL.Query().Get("fail"))
+
+	retriesMu.Lock()
+	retries[key]++
+	n := retries[key]
+	if n > fail {
+		retries[key] = 0
+	}
+	retriesMu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	if n <= fail {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "attempt %d failed", n)
+		return
+	}
+
+	fmt.Fprintf(w, "retry:%s", body)
+}
+
+func download(w http.ResponseWriter, r *http.Request) {
+	modtime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	http.ServeContent(w, r, "goz.txt", modtime, strings.NewReader(downloadContent))
+}
+
+func downloadNoRange(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, downloadContent)
+}
+
+// downloadWrongRange answer any range request from the first byte
+func downloadWrongRange(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Range") != "" {
+		r.Header.Set("Range", "bytes=0-")
+	}
+
+	modtime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	http.ServeContent(w, r, "goz.txt", modtime, strings.NewReader(downloadContent))
+}
+
+// downloadFlaky fail the range requests of the first segment,
+// count=1 reports how many requests were received
+func downloadFlaky(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+
+	retriesMu.Lock()
+	if r.URL.Query().Get("count") != "" {
+		fmt.Fprintf(w, "requests:%d", retries[key])
+		retriesMu.Unlock()
+		return
+	}
+	retries[key]++
+	retriesMu.Unlock()
+
+	rangeHeader := r.Header.Get("Range")
+	if strings.HasPrefix(rangeHeader, "bytes=0-") && rangeHeader != "bytes=0-0" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	modtime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	http.ServeContent(w, r, "goz.txt", modtime, strings.NewReader(downloadContent))
+}
+
 func put(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "PUT" {
 		fmt.Fprintf(w, "need put")
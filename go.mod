@@ -1,6 +1,6 @@
 module github.com/idoubi/goz
 
-go 1.13
+go 1.15
 
 require (
 	github.com/idoubi/goutils v1.1.0
@@ -8,5 +8,7 @@ require (
 	github.com/spf13/cast v1.5.0
 	github.com/tidwall/gjson v1.14.3
 	github.com/tidwall/pretty v1.2.1 // indirect
-	golang.org/x/net v0.0.0-20221004154528-8021a29435af // indirect
+	golang.org/x/crypto v0.0.0-20220722155217-630584e8d5aa // indirect
+	golang.org/x/net v0.0.0-20221004154528-8021a29435af
+	software.sslmate.com/src/go-pkcs12 v0.2.0
 )
@@ -1,8 +1,15 @@
 package goz
 
+import "context"
+
+// defaultClient shared by the package level helpers
+var defaultClient = NewClient()
+
 // NewClient new request object
 func NewClient(opts ...Options) *Request {
-	req := &Request{}
+	req := &Request{
+		codecs: defaultCodecs(),
+	}
 
 	opts0 := Options{}
 	if len(opts) > 0 {
@@ -16,30 +23,50 @@ func NewClient(opts ...Options) *Request {
 
 // Get send get request
 func Get(uri string, opts ...Options) (*Response, error) {
-	r := NewClient()
-	return r.Request("GET", uri, opts...)
+	return defaultClient.Request("GET", uri, opts...)
 }
 
 // Post send post request
 func Post(uri string, opts ...Options) (*Response, error) {
-	r := NewClient()
-	return r.Request("POST", uri, opts...)
+	return defaultClient.Request("POST", uri, opts...)
 }
 
 // Put send put request
 func Put(uri string, opts ...Options) (*Response, error) {
-	r := NewClient()
-	return r.Request("PUT", uri, opts...)
+	return defaultClient.Request("PUT", uri, opts...)
 }
 
 // Patch send patch request
 func Patch(uri string, opts ...Options) (*Response, error) {
-	r := NewClient()
-	return r.Request("PATCH", uri, opts...)
+	return defaultClient.Request("PATCH", uri, opts...)
 }
 
 // Delete send delete request
 func Delete(uri string, opts ...Options) (*Response, error) {
-	r := NewClient()
-	return r.Request("DELETE", uri, opts...)
+	return defaultClient.Request("DELETE", uri, opts...)
+}
+
+// GetCtx send get request with context
+func GetCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return defaultClient.RequestWithContext(ctx, "GET", uri, opts...)
+}
+
+// PostCtx send post request with context
+func PostCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return defaultClient.RequestWithContext(ctx, "POST", uri, opts...)
+}
+
+// PutCtx send put request with context
+func PutCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return defaultClient.RequestWithContext(ctx, "PUT", uri, opts...)
+}
+
+// PatchCtx send patch request with context
+func PatchCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return defaultClient.RequestWithContext(ctx, "PATCH", uri, opts...)
+}
+
+// DeleteCtx send delete request with context
+func DeleteCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return defaultClient.RequestWithContext(ctx, "DELETE", uri, opts...)
 }
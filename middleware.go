@@ -0,0 +1,27 @@
+package goz
+
+import "net/http"
+
+// Handler send a fully built request and return its response
+type Handler func(req *http.Request) (*Response, error)
+
+// Middleware wrap a handler, it can modify the request before calling next
+// and inspect or replace the response returned by next
+type Middleware func(next Handler) Handler
+
+// Use add middlewares to the client, the first one added is the outermost
+func (r *Request) Use(middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// chain wrap handler with middlewares
+func chain(handler Handler, middlewares []Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
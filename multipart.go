@@ -0,0 +1,236 @@
+package goz
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// multipartPart part of multipart body with its header built upfront
+type multipartPart struct {
+	data   FormData
+	header textproto.MIMEHeader
+	// size content size, -1 if unknown
+	size int64
+}
+
+// multipartForm multipart body streamed from contents, files and readers
+type multipartForm struct {
+	boundary string
+	parts    []multipartPart
+}
+
+// newMultipartForm build part headers, sizes of files are read from disk
+func newMultipartForm(data []FormData) *multipartForm {
+	form := &multipartForm{
+		boundary: multipart.NewWriter(nil).Boundary(),
+	}
+
+	for _, v := range data {
+		part := multipartPart{data: v, size: -1}
+
+		headers := map[string]interface{}{}
+		for key, value := range v.Headers {
+			headers[key] = value
+		}
+
+		switch {
+		case v.Contents != nil:
+			part.size = int64(len(v.Contents))
+		case v.Filepath != "":
+			if fi, err := os.Stat(v.Filepath); err == nil {
+				part.size = fi.Size()
+			}
+			if _, ok := headers["Content-Type"]; !ok {
+				if contentType, err := detectFileContentType(v.Filepath); err == nil {
+					headers["Content-Type"] = contentType
+				}
+			}
+		case v.Reader != nil:
+			if l, ok := v.Reader.(interface{ Len() int }); ok {
+				part.size = int64(l.Len())
+			}
+		default:
+			part.size = 0
+		}
+
+		if v.Filepath != "" && v.Filename == "" {
+			v.Filename = filepath.Base(v.Filepath)
+		}
+
+		arr := []string{
+			"form-data",
+			fmt.Sprintf("name=%q", v.Name),
+		}
+		if v.Filename != "" {
+			arr = append(arr, fmt.Sprintf("filename=%q", v.Filename))
+		}
+
+		h := make(textproto.MIMEHeader)
+
+		// set content disposition
+		h.Set("Content-Disposition", strings.Join(arr, "; "))
+
+		// set headers
+		for key, value := range headers {
+			if header, ok := value.(string); ok {
+				h.Set(key, header)
+			}
+		}
+
+		part.header = h
+		form.parts = append(form.parts, part)
+	}
+
+	return form
+}
+
+// contentType multipart content type with boundary
+func (f *multipartForm) contentType() string {
+	return "multipart/form-data; boundary=" + f.boundary
+}
+
+// contentLength get body size, -1 if any part size is unknown
+func (f *multipartForm) contentLength() int64 {
+	counter := &countWriter{}
+	bw := multipart.NewWriter(counter)
+	bw.SetBoundary(f.boundary)
+
+	var size int64
+	for _, part := range f.parts {
+		if part.size < 0 {
+			return -1
+		}
+		size += part.size
+		bw.CreatePart(part.header)
+	}
+	bw.Close()
+
+	return size + counter.n
+}
+
+// replayable get if the body can be built again, readers can only be read once
+func (f *multipartForm) replayable() bool {
+	for _, part := range f.parts {
+		if part.data.Reader != nil && part.data.Contents == nil && part.data.Filepath == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// body get body reader reporting to progress, parts are written through
+// a pipe on the first read
+func (f *multipartForm) body(progress func(done, total int64)) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	return &multipartBody{
+		pr:       pr,
+		write:    func() { pw.CloseWithError(f.write(pw)) },
+		total:    f.contentLength(),
+		progress: progress,
+	}
+}
+
+func (f *multipartForm) write(w io.Writer) error {
+	bw := multipart.NewWriter(w)
+	bw.SetBoundary(f.boundary)
+
+	for _, part := range f.parts {
+		p, err := bw.CreatePart(part.header)
+		if err != nil {
+			return err
+		}
+		if err := writePartContents(p, part.data); err != nil {
+			return err
+		}
+	}
+
+	return bw.Close()
+}
+
+func writePartContents(w io.Writer, v FormData) error {
+	switch {
+	case v.Contents != nil:
+		_, err := w.Write(v.Contents)
+		return err
+	case v.Filepath != "":
+		f, err := os.Open(v.Filepath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(w, f)
+		return err
+	case v.Reader != nil:
+		_, err := io.Copy(w, v.Reader)
+		return err
+	}
+
+	return nil
+}
+
+// multipartBody pipe reader starting the writer lazily so that
+// an unsent request doesn't leak the writing goroutine
+type multipartBody struct {
+	pr    *io.PipeReader
+	once  sync.Once
+	write func()
+
+	done     int64
+	total    int64
+	progress func(done, total int64)
+}
+
+func (b *multipartBody) Read(p []byte) (int, error) {
+	b.once.Do(func() {
+		go b.write()
+	})
+
+	n, err := b.pr.Read(p)
+	if n > 0 && b.progress != nil {
+		b.done += int64(n)
+		b.progress(b.done, b.total)
+	}
+
+	return n, err
+}
+
+func (b *multipartBody) Close() error {
+	return b.pr.Close()
+}
+
+// detectFileContentType detect content type from the first 512 bytes of file
+func detectFileContentType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf, err := ioutil.ReadAll(io.LimitReader(f, 512))
+	if err != nil {
+		return "", err
+	}
+
+	return http.DetectContentType(buf), nil
+}
+
+// countWriter count written bytes
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,264 @@
+package goz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultExpiryDelta seconds before expiry a token is refreshed in the background
+const defaultExpiryDelta = 10
+
+// OAuth2Config oauth2 token endpoint settings, the refresh token grant is
+// used if RefreshToken is set, otherwise the client credentials grant
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RefreshToken string
+
+	// send client credentials with basic auth instead of the form
+	BasicAuth bool
+
+	// extra form params sent to the token endpoint
+	Params map[string]interface{}
+
+	// refresh the token in the background ExpiryDelta seconds before it expires
+	ExpiryDelta float32
+
+	// client fetching tokens, it must not use this token source
+	Client *Request
+}
+
+// Token oauth2 token, Expiry is zero if the token doesn't expire
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresIn    int64     `json:"expires_in"`
+	Expiry       time.Time `json:"-"`
+}
+
+// OAuth2Error error response of the token endpoint
+type OAuth2Error struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// Error implement error interface
+func (e *OAuth2Error) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("oauth2: %d %s: %s", e.StatusCode, e.Code, e.Description)
+	}
+
+	return fmt.Sprintf("oauth2: %d %s", e.StatusCode, e.Code)
+}
+
+// TokenSource oauth2 Authenticator, tokens are cached until they expire and
+// fetched once for concurrent requests, a 401 response is retried once with a new token
+type TokenSource struct {
+	config OAuth2Config
+	client *Request
+
+	mu           sync.Mutex
+	token        *Token
+	refreshToken string
+	refreshing   bool
+
+	// serialize token requests
+	fetchMu sync.Mutex
+}
+
+// NewTokenSource new oauth2 token source
+func NewTokenSource(config OAuth2Config) *TokenSource {
+	client := config.Client
+	if client == nil {
+		client = NewClient()
+	}
+
+	return &TokenSource{
+		config:       config,
+		client:       client,
+		refreshToken: config.RefreshToken,
+	}
+}
+
+// Token get a valid token, fetching a new one if the cached token expired
+func (s *TokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	tok := s.token
+	if tok.valid() {
+		if tok.expiresWithin(s.expiryDelta()) && !s.refreshing {
+			s.refreshing = true
+			go s.refresh(tok)
+		}
+		s.mu.Unlock()
+
+		return tok, nil
+	}
+	s.mu.Unlock()
+
+	return s.fetch(ctx, tok)
+}
+
+// Authenticate implement Authenticator
+func (s *TokenSource) Authenticate(req *http.Request) error {
+	tok, err := s.Token(req.Context())
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", tok.authorization())
+
+	return nil
+}
+
+// Challenge implement Challenger, the token sent with a 401 response is
+// dropped and the request is retried with a new one
+func (s *TokenSource) Challenge(req *http.Request, resp *http.Response) (bool, error) {
+	sent := req.Header.Get("Authorization")
+	if sent == "" {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	if s.token != nil && s.token.authorization() == sent {
+		s.token = nil
+	}
+	s.mu.Unlock()
+
+	return true, nil
+}
+
+// refresh fetch a new token in the background before stale expires
+func (s *TokenSource) refresh(stale *Token) {
+	s.fetch(context.Background(), stale)
+
+	s.mu.Lock()
+	s.refreshing = false
+	s.mu.Unlock()
+}
+
+// fetch request a new token unless stale was already replaced
+func (s *TokenSource) fetch(ctx context.Context, stale *Token) (*Token, error) {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	s.mu.Lock()
+	cur, refreshToken := s.token, s.refreshToken
+	s.mu.Unlock()
+
+	if cur != stale && cur.valid() {
+		return cur, nil
+	}
+
+	tok, err := s.requestToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.token = tok
+	if tok.RefreshToken != "" {
+		s.refreshToken = tok.RefreshToken
+	}
+	s.mu.Unlock()
+
+	return tok, nil
+}
+
+// requestToken send the token request
+func (s *TokenSource) requestToken(ctx context.Context, refreshToken string) (*Token, error) {
+	form := make(map[string]interface{}, len(s.config.Params)+5)
+	for k, v := range s.config.Params {
+		form[k] = v
+	}
+
+	if refreshToken != "" {
+		form["grant_type"] = "refresh_token"
+		form["refresh_token"] = refreshToken
+	} else {
+		form["grant_type"] = "client_credentials"
+	}
+	if len(s.config.Scopes) > 0 {
+		form["scope"] = strings.Join(s.config.Scopes, " ")
+	}
+
+	opts := Options{
+		FormParams: form,
+		Headers: map[string]interface{}{
+			"Accept": "application/json",
+		},
+	}
+	if s.config.BasicAuth {
+		opts.Auth = BasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	} else {
+		form["client_id"] = s.config.ClientID
+		if s.config.ClientSecret != "" {
+			form["client_secret"] = s.config.ClientSecret
+		}
+	}
+
+	var (
+		tok    Token
+		errRes OAuth2Error
+	)
+	opts.Result = &tok
+	opts.ErrorResult = &errRes
+
+	resp, err := s.client.PostCtx(ctx, s.config.TokenURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if code := resp.GetStatusCode(); code < 200 || code >= 300 {
+		errRes.StatusCode = code
+		if errRes.Code == "" {
+			errRes.Code = http.StatusText(code)
+		}
+		return nil, &errRes
+	}
+	if tok.AccessToken == "" {
+		return nil, &OAuth2Error{StatusCode: resp.GetStatusCode(), Code: "missing access_token"}
+	}
+
+	if tok.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+
+	return &tok, nil
+}
+
+func (s *TokenSource) expiryDelta() time.Duration {
+	if s.config.ExpiryDelta > 0 {
+		return seconds(s.config.ExpiryDelta)
+	}
+
+	return defaultExpiryDelta * time.Second
+}
+
+// valid token is set and not expired
+func (t *Token) valid() bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Before(t.Expiry))
+}
+
+// expiresWithin token expires in d
+func (t *Token) expiresWithin(d time.Duration) bool {
+	return !t.Expiry.IsZero() && time.Until(t.Expiry) < d
+}
+
+// authorization value of the Authorization header
+func (t *Token) authorization() string {
+	tokenType := t.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+
+	return tokenType + " " + t.AccessToken
+}
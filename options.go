@@ -2,6 +2,7 @@ package goz
 
 import (
 	"crypto/tls"
+	"net/http"
 	"strings"
 	"time"
 )
@@ -15,12 +16,103 @@ type Options struct {
 	Query        interface{}
 	Headers      map[string]interface{}
 	Cookies      interface{}
-	FormParams   map[string]interface{}
+	FormParams   interface{}
 	JSON         interface{}
 	XML          interface{}
 	Multipart    []FormData
+	Body         interface{}
+	Payload      interface{}
 	Proxy        string
 	Certificates []tls.Certificate
+	Retry        *Retry
+	HTTPErrors   bool
+
+	// Auth add credentials to requests, see BasicAuth, BearerAuth,
+	// APIKeyAuth, APIKeyQueryAuth and DigestAuth
+	Auth Authenticator
+
+	// Signer sign the built request before every attempt, see SigV4 and HMACSigner
+	Signer Signer
+
+	// encoding of Query and FormParams, the zero values EncodeDefault and
+	// QueryDefault keep the client options, set EncodeRepeat or QueryReplace
+	// to switch a request back to the defaults
+	EncodeStyle EncodeStyle
+	QueryMode   QueryMode
+
+	// session keeps cookies set by responses for the following requests,
+	// a public suffix aware *CookieJar is created if CookieJar is not set.
+	// Session is a client option read by NewClient and SetOptions, it is
+	// ignored per request, pass CookieJar to give a request its own cookies
+	Session   bool
+	CookieJar http.CookieJar
+
+	// redirect policy, MaxRedirects defaults to 10, DisableRedirects returns
+	// the redirect response, KeepAuthOnRedirect keeps auth headers on hops
+	// back to the original host
+	DisableRedirects        bool
+	MaxRedirects            int
+	ForbidRedirectDowngrade bool
+	KeepAuthOnRedirect      bool
+
+	// StreamBody leave response body unread, read it with Response.BodyReader,
+	// Timeout then only limits the wait for the response headers and reading
+	// the body is limited by the request context, close the body when done.
+	// MaxBodySize limit buffered response body size in bytes
+	StreamBody  bool
+	MaxBodySize int64
+
+	// download, checksum is formatted as sha256:<hex> or md5:<hex>,
+	// segments are downloaded in parallel range requests if the server supports it,
+	// progress total is -1 when the size is unknown
+	DownloadProgress func(done, total int64)
+	DownloadSegments int
+	Checksum         string
+
+	// UploadProgress report sent bytes of multipart body
+	UploadProgress func(done, total int64)
+
+	// decode 2xx response body into Result and 4xx, 5xx into ErrorResult
+	Result                interface{}
+	ErrorResult           interface{}
+	DisallowUnknownFields bool
+
+	// tls, server certificates are verified unless InsecureSkipVerify is set
+	RootCAFile         string
+	RootCAPEM          []byte
+	TLSMinVersion      uint16
+	TLSMaxVersion      uint16
+	CipherSuites       []uint16
+	ServerName         string
+	InsecureSkipVerify bool
+
+	// client certificate loaded from pem files or a pkcs12 bundle,
+	// reload picks up rotated files on the next tls handshake
+	CertFile           string
+	KeyFile            string
+	PKCS12File         string
+	PKCS12Password     string
+	ReloadCertificates bool
+
+	// pinning, base64 sha256 public key hashes and hex sha256 certificate
+	// fingerprints, the request fails with *PinError if none matches
+	PublicKeyPins   []string
+	CertificatePins []string
+
+	// per phase timeouts in seconds, Timeout limits the whole request including
+	// the body unless StreamBody is set, IdleConnTimeout idle pooled connections,
+	// dial and tls handshake default to 30 and 10, response header isn't limited
+	// by default
+	DialTimeout           float32
+	TLSHandshakeTimeout   float32
+	ResponseHeaderTimeout float32
+
+	// connection pool, shared by all requests sent by the same client
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     float32
+	DisableKeepAlives   bool
 }
 
 func mergeOptions(opts0 Options, opts ...Options) Options {
@@ -43,6 +135,36 @@ func mergeOptions(opts0 Options, opts ...Options) Options {
 		if opt.Cookies != nil {
 			opts0.Cookies = opt.Cookies
 		}
+		if opt.Auth != nil {
+			opts0.Auth = opt.Auth
+		}
+		if opt.Signer != nil {
+			opts0.Signer = opt.Signer
+		}
+		if opt.DialTimeout > 0 {
+			opts0.DialTimeout = opt.DialTimeout
+		}
+		if opt.TLSHandshakeTimeout > 0 {
+			opts0.TLSHandshakeTimeout = opt.TLSHandshakeTimeout
+		}
+		if opt.ResponseHeaderTimeout > 0 {
+			opts0.ResponseHeaderTimeout = opt.ResponseHeaderTimeout
+		}
+		if opt.CookieJar != nil {
+			opts0.CookieJar = opt.CookieJar
+		}
+		if opt.DisableRedirects {
+			opts0.DisableRedirects = opt.DisableRedirects
+		}
+		if opt.MaxRedirects > 0 {
+			opts0.MaxRedirects = opt.MaxRedirects
+		}
+		if opt.ForbidRedirectDowngrade {
+			opts0.ForbidRedirectDowngrade = opt.ForbidRedirectDowngrade
+		}
+		if opt.KeepAuthOnRedirect {
+			opts0.KeepAuthOnRedirect = opt.KeepAuthOnRedirect
+		}
 		if opt.FormParams != nil {
 			opts0.FormParams = opt.FormParams
 		}
@@ -55,12 +177,114 @@ func mergeOptions(opts0 Options, opts ...Options) Options {
 		if opt.Multipart != nil {
 			opts0.Multipart = opt.Multipart
 		}
+		if opt.Body != nil {
+			opts0.Body = opt.Body
+		}
+		if opt.Payload != nil {
+			opts0.Payload = opt.Payload
+		}
+		if opt.QueryMode != QueryDefault {
+			opts0.QueryMode = opt.QueryMode
+		}
+		if opt.EncodeStyle != EncodeDefault {
+			opts0.EncodeStyle = opt.EncodeStyle
+		}
 		if opt.Proxy != "" {
 			opts0.Proxy = opt.Proxy
 		}
 		if opt.Certificates != nil {
 			opts0.Certificates = opt.Certificates
 		}
+		if opt.RootCAFile != "" {
+			opts0.RootCAFile = opt.RootCAFile
+		}
+		if opt.RootCAPEM != nil {
+			opts0.RootCAPEM = opt.RootCAPEM
+		}
+		if opt.TLSMinVersion > 0 {
+			opts0.TLSMinVersion = opt.TLSMinVersion
+		}
+		if opt.TLSMaxVersion > 0 {
+			opts0.TLSMaxVersion = opt.TLSMaxVersion
+		}
+		if opt.CipherSuites != nil {
+			opts0.CipherSuites = opt.CipherSuites
+		}
+		if opt.ServerName != "" {
+			opts0.ServerName = opt.ServerName
+		}
+		if opt.InsecureSkipVerify {
+			opts0.InsecureSkipVerify = opt.InsecureSkipVerify
+		}
+		if opt.CertFile != "" {
+			opts0.CertFile = opt.CertFile
+		}
+		if opt.KeyFile != "" {
+			opts0.KeyFile = opt.KeyFile
+		}
+		if opt.PKCS12File != "" {
+			opts0.PKCS12File = opt.PKCS12File
+		}
+		if opt.PKCS12Password != "" {
+			opts0.PKCS12Password = opt.PKCS12Password
+		}
+		if opt.ReloadCertificates {
+			opts0.ReloadCertificates = opt.ReloadCertificates
+		}
+		if opt.PublicKeyPins != nil {
+			opts0.PublicKeyPins = opt.PublicKeyPins
+		}
+		if opt.CertificatePins != nil {
+			opts0.CertificatePins = opt.CertificatePins
+		}
+		if opt.Result != nil {
+			opts0.Result = opt.Result
+		}
+		if opt.ErrorResult != nil {
+			opts0.ErrorResult = opt.ErrorResult
+		}
+		if opt.DisallowUnknownFields {
+			opts0.DisallowUnknownFields = opt.DisallowUnknownFields
+		}
+		if opt.HTTPErrors {
+			opts0.HTTPErrors = opt.HTTPErrors
+		}
+		if opt.StreamBody {
+			opts0.StreamBody = opt.StreamBody
+		}
+		if opt.MaxBodySize > 0 {
+			opts0.MaxBodySize = opt.MaxBodySize
+		}
+		if opt.DownloadProgress != nil {
+			opts0.DownloadProgress = opt.DownloadProgress
+		}
+		if opt.UploadProgress != nil {
+			opts0.UploadProgress = opt.UploadProgress
+		}
+		if opt.DownloadSegments > 0 {
+			opts0.DownloadSegments = opt.DownloadSegments
+		}
+		if opt.Checksum != "" {
+			opts0.Checksum = opt.Checksum
+		}
+		if opt.Retry != nil {
+			opts0.Retry = opt.Retry
+		}
+		if opt.MaxIdleConns > 0 {
+			opts0.MaxIdleConns = opt.MaxIdleConns
+		}
+		if opt.MaxIdleConnsPerHost > 0 {
+			opts0.MaxIdleConnsPerHost = opt.MaxIdleConnsPerHost
+		}
+		if opt.MaxConnsPerHost > 0 {
+			opts0.MaxConnsPerHost = opt.MaxConnsPerHost
+		}
+		if opt.IdleConnTimeout > 0 {
+			opts0.IdleConnTimeout = opt.IdleConnTimeout
+		}
+		if opt.DisableKeepAlives {
+			opts0.DisableKeepAlives = opt.DisableKeepAlives
+		}
 	}
 
 	return opts0
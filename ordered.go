@@ -0,0 +1,170 @@
+package goz
+
+import (
+	"net/url"
+	"strings"
+)
+
+// QueryMode how Query is merged with the query already in the uri
+type QueryMode int
+
+const (
+	// QueryDefault keep the mode of the client, QueryReplace if it isn't set
+	QueryDefault QueryMode = iota
+	// QueryReplace Query params replace uri params with the same key in place, the default
+	QueryReplace
+	// QueryAppend append Query params after the uri query, keeping params with the same key
+	QueryAppend
+)
+
+// valuesWriter writer of encoded params, implemented by url.Values and *OrderedValues
+type valuesWriter interface {
+	Add(key, value string)
+	Set(key, value string)
+}
+
+// orderedPair key value pair, raw is the literal pair parsed from a query string
+type orderedPair struct {
+	key   string
+	value string
+	raw   string
+}
+
+// OrderedValues params keeping insertion order, unlike url.Values
+// the encoded string is not sorted by key
+type OrderedValues struct {
+	pairs []orderedPair
+}
+
+// NewOrderedValues new ordered params object
+func NewOrderedValues() *OrderedValues {
+	return &OrderedValues{}
+}
+
+// ParseOrderedValues parse query string keeping its order and literal encoding
+func ParseOrderedValues(query string) *OrderedValues {
+	v := NewOrderedValues()
+
+	for _, raw := range strings.Split(query, "&") {
+		if raw == "" {
+			continue
+		}
+
+		key, value := raw, ""
+		if i := strings.Index(raw, "="); i >= 0 {
+			key, value = raw[:i], raw[i+1:]
+		}
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+		if val, err := url.QueryUnescape(value); err == nil {
+			value = val
+		}
+
+		v.pairs = append(v.pairs, orderedPair{key: key, value: value, raw: raw})
+	}
+
+	return v
+}
+
+// Add add value to key
+func (v *OrderedValues) Add(key, value string) {
+	v.pairs = append(v.pairs, orderedPair{key: key, value: value})
+}
+
+// Set set key to value, the first existing position of key is kept
+func (v *OrderedValues) Set(key, value string) {
+	v.replace(key, []orderedPair{{key: key, value: value}})
+}
+
+// Get get the first value of key
+func (v *OrderedValues) Get(key string) string {
+	for _, p := range v.pairs {
+		if p.key == key {
+			return p.value
+		}
+	}
+
+	return ""
+}
+
+// Del delete all values of key
+func (v *OrderedValues) Del(key string) {
+	pairs := v.pairs[:0]
+	for _, p := range v.pairs {
+		if p.key != key {
+			pairs = append(pairs, p)
+		}
+	}
+
+	v.pairs = pairs
+}
+
+// Len get count of pairs
+func (v *OrderedValues) Len() int {
+	return len(v.pairs)
+}
+
+// Encode encode params in insertion order, parsed pairs keep their literal encoding
+func (v *OrderedValues) Encode() string {
+	var b strings.Builder
+
+	for i, p := range v.pairs {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		if p.raw != "" {
+			b.WriteString(p.raw)
+			continue
+		}
+		b.WriteString(url.QueryEscape(p.key))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(p.value))
+	}
+
+	return b.String()
+}
+
+// merge merge params into v, appending them or replacing the pairs with the same key
+func (v *OrderedValues) merge(params *OrderedValues, mode QueryMode) {
+	if mode == QueryAppend {
+		v.pairs = append(v.pairs, params.pairs...)
+		return
+	}
+
+	// group params by key keeping the order of first appearance
+	keys := []string{}
+	groups := map[string][]orderedPair{}
+	for _, p := range params.pairs {
+		if _, ok := groups[p.key]; !ok {
+			keys = append(keys, p.key)
+		}
+		groups[p.key] = append(groups[p.key], p)
+	}
+
+	for _, key := range keys {
+		v.replace(key, groups[key])
+	}
+}
+
+// replace replace all pairs of key with pairs at the first position of key
+func (v *OrderedValues) replace(key string, pairs []orderedPair) {
+	result := make([]orderedPair, 0, len(v.pairs)+len(pairs))
+
+	replaced := false
+	for _, p := range v.pairs {
+		if p.key != key {
+			result = append(result, p)
+			continue
+		}
+		if !replaced {
+			result = append(result, pairs...)
+			replaced = true
+		}
+	}
+	if !replaced {
+		result = append(result, pairs...)
+	}
+
+	v.pairs = result
+}
@@ -0,0 +1,85 @@
+package goz
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PinError server certificate doesn't match any of the configured pins
+type PinError struct {
+	// PublicKeyPin base64 sha256 hash of the leaf certificate public key
+	PublicKeyPin string
+	// CertificatePin hex sha256 fingerprint of the leaf certificate
+	CertificatePin string
+}
+
+// Error implement error interface
+func (e *PinError) Error() string {
+	return fmt.Sprintf("certificate pin mismatch: public key sha256/%s, certificate %s", e.PublicKeyPin, e.CertificatePin)
+}
+
+// PublicKeyPin get the base64 sha256 hash of a certificate public key (SPKI)
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// CertificatePin get the hex sha256 fingerprint of a certificate
+func CertificatePin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func hasPins(opts Options) bool {
+	return len(opts.PublicKeyPins) > 0 || len(opts.CertificatePins) > 0
+}
+
+// verifyPins build tls.Config.VerifyPeerCertificate checking pins,
+// any pin matching passes so backup pins can be listed.
+// public key pins match the leaf or any certificate in the verified chains,
+// certificate pins match the leaf only
+func verifyPins(publicKeyPins, certificatePins []string) func([][]byte, [][]*x509.Certificate) error {
+	keyPins := make(map[string]bool, len(publicKeyPins))
+	for _, pin := range publicKeyPins {
+		keyPins[strings.TrimPrefix(pin, "sha256/")] = true
+	}
+	certPins := make(map[string]bool, len(certificatePins))
+	for _, pin := range certificatePins {
+		certPins[strings.ToLower(strings.Replace(pin, ":", "", -1))] = true
+	}
+
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("no server certificate to check pins")
+		}
+
+		leaf, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+
+		pinErr := &PinError{
+			PublicKeyPin:   PublicKeyPin(leaf),
+			CertificatePin: CertificatePin(leaf),
+		}
+
+		if keyPins[pinErr.PublicKeyPin] || certPins[pinErr.CertificatePin] {
+			return nil
+		}
+
+		for _, chain := range verifiedChains {
+			for _, cert := range chain {
+				if keyPins[PublicKeyPin(cert)] {
+					return nil
+				}
+			}
+		}
+
+		return pinErr
+	}
+}
@@ -0,0 +1,65 @@
+package goz
+
+import (
+	"net/http"
+)
+
+// defaultMaxRedirects redirects followed when Options.MaxRedirects is not set
+const defaultMaxRedirects = 10
+
+// Redirect a redirect response followed before the final response
+type Redirect struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Location   string
+}
+
+// checkRedirect apply the redirect policy and record the redirect history
+func (c *call) checkRedirect(req *http.Request, via []*http.Request) error {
+	prev := via[len(via)-1]
+
+	if c.opts.DisableRedirects {
+		// return the redirect response
+		return http.ErrUseLastResponse
+	}
+
+	max := c.opts.MaxRedirects
+	if max <= 0 {
+		max = defaultMaxRedirects
+	}
+	if len(via) > max {
+		return ErrTooManyRedirects
+	}
+
+	if c.opts.ForbidRedirectDowngrade && prev.URL.Scheme == "https" && req.URL.Scheme == "http" {
+		return ErrRedirectDowngrade
+	}
+
+	if c.opts.KeepAuthOnRedirect && req.URL.Host == via[0].URL.Host {
+		// auth headers are dropped after a hop to another host,
+		// restore them when coming back to the original host
+		for _, name := range []string{"Authorization", "Proxy-Authorization"} {
+			if v, ok := via[0].Header[name]; ok && req.Header.Get(name) == "" {
+				req.Header[name] = v
+			}
+		}
+	}
+
+	// only followed hops are recorded
+	if req.Response != nil {
+		c.redirects = append(c.redirects, Redirect{
+			Method:     prev.Method,
+			URL:        prev.URL.String(),
+			StatusCode: req.Response.StatusCode,
+			Location:   req.URL.String(),
+		})
+	}
+
+	return nil
+}
+
+// GetRedirectHistory get the redirects followed before the response, in order
+func (r *Response) GetRedirectHistory() []Redirect {
+	return r.redirects
+}
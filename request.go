@@ -2,7 +2,7 @@ package goz
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
@@ -10,34 +10,59 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
-	"mime/multipart"
 	"net/http"
+	"net/http/httptrace"
 	"net/http/httputil"
-	"net/textproto"
-	"net/url"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/idoubi/goutils/convert"
 	"github.com/spf13/cast"
 )
 
-// Request object
+// Request object, a long-lived client that is safe for concurrent use
+// and reuses connections between requests
 type Request struct {
-	opts Options
-	cli  *http.Client
-	req  *http.Request
-	body io.Reader
+	mu          sync.RWMutex
+	opts        Options
+	tr          *http.Transport
+	trErr       error
+	middlewares []Middleware
+	codecs      map[string]Codec
+	jar         http.CookieJar
 }
 
-// FormData: multipart form-data
+// call per request state
+type call struct {
+	opts   Options
+	tr     *http.Transport
+	jar    http.CookieJar
+	cli    *http.Client
+	req    *http.Request
+	body   io.Reader
+	codecs map[string]Codec
+
+	redirects []Redirect
+	trace     *tracer
+
+	// set for bodies http.NewRequest can't measure or replay
+	contentLength int64
+	getBody       func() (io.ReadCloser, error)
+	// body sent again by retries and auth challenges when it differs from getBody
+	replayBody func() (io.ReadCloser, error)
+}
+
+// FormData: multipart form-data, contents are read from Contents,
+// Filepath or Reader, files and readers are streamed
 type FormData struct {
 	Name     string
 	Contents []byte
 	Filename string
 	Filepath string
+	Reader   io.Reader
 	Headers  map[string]interface{}
 }
 
@@ -71,79 +96,221 @@ func (r *Request) Options(uri string, opts ...Options) (*Response, error) {
 	return r.Request("OPTIONS", uri, opts...)
 }
 
-// SetOptions: set request options
+// GetCtx send get request with context
+func (r *Request) GetCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return r.RequestWithContext(ctx, "GET", uri, opts...)
+}
+
+// PostCtx send post request with context
+func (r *Request) PostCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return r.RequestWithContext(ctx, "POST", uri, opts...)
+}
+
+// PutCtx send put request with context
+func (r *Request) PutCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return r.RequestWithContext(ctx, "PUT", uri, opts...)
+}
+
+// PatchCtx send patch request with context
+func (r *Request) PatchCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return r.RequestWithContext(ctx, "PATCH", uri, opts...)
+}
+
+// DeleteCtx send delete request with context
+func (r *Request) DeleteCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return r.RequestWithContext(ctx, "DELETE", uri, opts...)
+}
+
+// OptionsCtx send options request with context
+func (r *Request) OptionsCtx(ctx context.Context, uri string, opts ...Options) (*Response, error) {
+	return r.RequestWithContext(ctx, "OPTIONS", uri, opts...)
+}
+
+// SetOptions: set request options,
+// the transport is rebuilt so new connection settings take effect
+// invalid transport settings are returned by the following requests,
+// cookies of an existing session are kept
 func (r *Request) SetOptions(opts Options) {
+	tr, err := newTransport(opts)
+
+	r.mu.Lock()
+	old := r.tr
 	r.opts = opts
+	r.tr = tr
+	r.trErr = err
+
+	switch {
+	case opts.CookieJar != nil:
+		r.jar = opts.CookieJar
+	case !opts.Session:
+		r.jar = nil
+	case r.jar == nil:
+		jar, jarErr := NewCookieJar()
+		if r.trErr == nil {
+			r.trErr = jarErr
+		}
+		if jarErr == nil {
+			r.jar = jar
+		}
+	}
+	r.mu.Unlock()
+
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+}
+
+// CloseIdleConnections close idle connections kept in the connection pool
+func (r *Request) CloseIdleConnections() {
+	r.mu.RLock()
+	tr := r.tr
+	r.mu.RUnlock()
+
+	if tr != nil {
+		tr.CloseIdleConnections()
+	}
 }
 
 // Request send request
 func (r *Request) Request(method, uri string, opts ...Options) (*Response, error) {
-	r.opts = mergeOptions(r.opts, opts...)
+	return r.RequestWithContext(context.Background(), method, uri, opts...)
+}
 
-	if !strings.HasPrefix(uri, "http") && strings.HasPrefix(r.opts.BaseURI, "http") {
-		uri = r.opts.BaseURI + uri
+// RequestWithContext send request with context,
+// cancel the context to abort the request and the response stream
+func (r *Request) RequestWithContext(ctx context.Context, method, uri string, opts ...Options) (*Response, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context")
 	}
 
-	if r.opts.Headers == nil {
-		r.opts.Headers = make(map[string]interface{})
+	r.mu.RLock()
+	c := &call{
+		opts:   mergeOptions(r.opts, opts...),
+		tr:     r.tr,
+		jar:    r.jar,
+		codecs: r.codecs,
 	}
+	trErr := r.trErr
+	r.mu.RUnlock()
+
+	if c.codecs == nil {
+		c.codecs = defaultCodecs()
+	}
+
+	dedicated := transportOverridden(opts...)
+	if trErr != nil && !dedicated {
+		return nil, trErr
+	}
+
+	if !strings.HasPrefix(uri, "http") && strings.HasPrefix(c.opts.BaseURI, "http") {
+		uri = c.opts.BaseURI + uri
+	}
+
+	// copy headers, body parsing may add the content type
+	headers := make(map[string]interface{}, len(c.opts.Headers))
+	for k, v := range c.opts.Headers {
+		headers[k] = v
+	}
+	c.opts.Headers = headers
 
 	switch method {
 	case http.MethodGet, http.MethodDelete:
-		req, err := http.NewRequest(method, uri, nil)
+		req, err := http.NewRequestWithContext(ctx, method, uri, nil)
 		if err != nil {
 			return nil, err
 		}
 
-		r.req = req
+		c.req = req
 	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodOptions:
 		// parse body
-		r.parseBody()
+		if err := c.parseBody(); err != nil {
+			return nil, err
+		}
 
-		req, err := http.NewRequest(method, uri, r.body)
+		req, err := http.NewRequestWithContext(ctx, method, uri, c.body)
 		if err != nil {
 			return nil, err
 		}
+		if c.getBody != nil {
+			req.GetBody = c.getBody
+		}
+		if c.contentLength > 0 {
+			req.ContentLength = c.contentLength
+		}
 
-		r.req = req
+		c.req = req
 	default:
 		return nil, errors.New("invalid request method")
 	}
 
 	// parseOptions
-	r.parseOptions()
+	c.parseOptions()
 
 	// parseClient
-	r.parseClient()
+	if err := c.parseClient(dedicated); err != nil {
+		return nil, err
+	}
 
 	// parse query
-	r.parseQuery()
+	if err := c.parseQuery(); err != nil {
+		return nil, err
+	}
 
 	// parse headers
-	r.parseHeaders()
+	c.parseHeaders()
 
 	// parse cookies
-	r.parseCookies()
+	c.parseCookies()
 
-	if r.opts.Debug {
+	r.mu.RLock()
+	handler := chain(c.send, r.middlewares)
+	r.mu.RUnlock()
+
+	return handler(c.req)
+}
+
+// send send the built request, the terminal handler of the middleware chain
+func (c *call) send(req *http.Request) (*Response, error) {
+	// trace connection phases
+	c.trace = &tracer{}
+	c.req = req.WithContext(httptrace.WithClientTrace(req.Context(), c.trace.clientTrace()))
+
+	if c.opts.Debug {
 		// print request object
-		dump, err := httputil.DumpRequest(r.req, true)
+		dump, err := httputil.DumpRequest(c.req, true)
 		if err == nil {
 			log.Printf("\n%s\n\n", dump)
 		}
 	}
 
-	_resp, err := r.cli.Do(r.req)
+	var (
+		_resp    *http.Response
+		attempts int
+		err      error
+	)
+	if c.opts.StreamBody {
+		_resp, attempts, err = c.doStream()
+	} else {
+		_resp, attempts, err = c.do(req.Context())
+	}
+	err = wrapTransportError(err)
+	c.trace.finish()
 
 	resp := &Response{
-		resp: _resp,
-		req:  r.req,
-		err:  err,
+		ctx:       req.Context(),
+		resp:      _resp,
+		req:       c.req,
+		err:       err,
+		attempts:  attempts,
+		strict:    c.opts.DisallowUnknownFields,
+		codecs:    c.codecs,
+		redirects: c.redirects,
+		trace:     c.trace,
 	}
 
 	// request failed
 	if err != nil {
-		if r.opts.Debug {
+		if c.opts.Debug {
 			// print response err
 			fmt.Println(err)
 		}
@@ -151,278 +318,417 @@ func (r *Request) Request(method, uri string, opts ...Options) (*Response, error
 		return resp, err
 	}
 
-	// stream response
-	if strings.HasPrefix(resp.GetHeaderLine("content-type"), "text/event-stream") {
-		resp.parseSteam()
+	// leave body unread for the caller
+	if c.opts.StreamBody {
+		resp.unread = true
+
+		// non-2xx status as error
+		if c.opts.HTTPErrors && (_resp.StatusCode < 200 || _resp.StatusCode > 299) {
+			snippet, _ := ioutil.ReadAll(io.LimitReader(_resp.Body, statusErrorBodySize))
+			_resp.Body.Close()
+			resp.unread = false
+			resp.err = newStatusError(_resp, snippet)
+			return resp, resp.err
+		}
 
 		return resp, nil
 	}
 
-	body, err := ioutil.ReadAll(_resp.Body)
-	_resp.Body.Close()
+	resp.readBody(c.opts.MaxBodySize)
+	c.trace.finish()
 
-	resp.body = body
-	resp.err = err
+	if errors.Is(resp.err, ErrBodyTooLarge) {
+		return resp, resp.err
+	}
 
-	if r.opts.Debug {
+	if c.opts.Debug && resp.stream == nil {
 		// print response data
 		body, _ := resp.GetBody()
 		fmt.Println(string(body))
 	}
 
+	if resp.stream == nil && resp.err == nil {
+		if err := resp.decodeResult(c.opts.Result, c.opts.ErrorResult); err != nil {
+			resp.err = err
+			return resp, err
+		}
+	}
+
+	// non-2xx status as error
+	if c.opts.HTTPErrors && (_resp.StatusCode < 200 || _resp.StatusCode > 299) {
+		resp.err = newStatusError(_resp, resp.body)
+		return resp, resp.err
+	}
+
 	return resp, nil
 }
 
-func (r *Request) parseOptions() {
+func (c *call) parseOptions() {
 	// default timeout 30s
-	if r.opts.Timeout == 0 {
-		r.opts.Timeout = 30
+	if c.opts.Timeout == 0 {
+		c.opts.Timeout = 30
 	}
-	r.opts.timeout = time.Duration(r.opts.Timeout*1000) * time.Millisecond
+	c.opts.timeout = time.Duration(c.opts.Timeout*1000) * time.Millisecond
 }
 
-func (r *Request) parseClient() {
-	tlsConfig := &tls.Config{}
-	if len(r.opts.Certificates) > 0 {
-		tlsConfig.Certificates = r.opts.Certificates
-	} else {
-		tlsConfig.InsecureSkipVerify = true
+func (c *call) parseClient(dedicated bool) error {
+	if dedicated || c.tr == nil {
+		// transport settings overridden for this call only,
+		// use a one-off transport and don't keep the connection
+		tr, err := newTransport(c.opts)
+		if err != nil {
+			return err
+		}
+
+		c.tr = tr
+		c.req.Close = true
 	}
 
-	tr := &http.Transport{
-		TLSClientConfig: tlsConfig,
+	jar := c.jar
+	if c.opts.CookieJar != nil {
+		jar = c.opts.CookieJar
 	}
 
-	if r.opts.Proxy != "" {
-		proxy, err := url.Parse(r.opts.Proxy)
-		if err == nil {
-			tr.Proxy = http.ProxyURL(proxy)
+	// a streamed body is read after the request returns,
+	// its timeout is applied by doStream
+	timeout := c.opts.timeout
+	if c.opts.StreamBody {
+		timeout = 0
+	}
+
+	c.cli = &http.Client{
+		Timeout:       timeout,
+		Transport:     c.tr,
+		Jar:           jar,
+		CheckRedirect: c.checkRedirect,
+	}
+
+	return nil
+}
+
+// doStream send the request with Timeout limiting the wait for the response
+// headers only, the body is read until the caller closes it or cancels the context
+func (c *call) doStream() (*http.Response, int, error) {
+	ctx, cancel := context.WithCancel(c.req.Context())
+	c.req = c.req.WithContext(ctx)
+
+	var fired int32
+	timer := time.AfterFunc(c.opts.timeout, func() {
+		atomic.StoreInt32(&fired, 1)
+		cancel()
+	})
+
+	resp, attempts, err := c.do(ctx)
+
+	if !timer.Stop() && atomic.LoadInt32(&fired) == 1 {
+		if resp != nil {
+			resp.Body.Close()
 		}
+		cancel()
+
+		return nil, attempts, &TimeoutError{Err: fmt.Errorf("timeout awaiting response headers: %w", context.DeadlineExceeded)}
 	}
+	if err != nil {
+		cancel()
+		return resp, attempts, err
+	}
+
+	// release the context with the body
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, attempts, nil
+}
+
+// cancelReadCloser cancel the request context when the body is closed
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelReadCloser) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
 
-	r.cli = &http.Client{
-		Timeout:   r.opts.timeout,
-		Transport: tr,
+// rewindReader seek back to start before the first read,
+// a copy returned by GetBody may have been read before sending
+type rewindReader struct {
+	rs      io.ReadSeeker
+	start   int64
+	rewound bool
+}
+
+func (r *rewindReader) Read(p []byte) (int, error) {
+	if !r.rewound {
+		if _, err := r.rs.Seek(r.start, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r.rewound = true
 	}
 
-	// prevents re-use of TCP connections between requests to the same host
-	r.req.Close = true
+	return r.rs.Read(p)
 }
 
-func (r *Request) parseQuery() {
-	switch r.opts.Query.(type) {
+func (c *call) parseQuery() error {
+	var q *OrderedValues
+
+	switch query := c.opts.Query.(type) {
+	case nil:
+		return nil
 	case string:
-		str := r.opts.Query.(string)
-		r.req.URL.RawQuery = str
-	case map[string]string:
-		q := r.req.URL.Query()
-		for k, v := range r.opts.Query.(map[string]string) {
-			q.Set(k, v)
-		}
-		r.req.URL.RawQuery = q.Encode()
-	case map[string]interface{}:
-		q := r.req.URL.Query()
-		for k, v := range r.opts.Query.(map[string]interface{}) {
-			if vv, ok := v.(string); ok {
-				q.Set(k, vv)
-				continue
-			}
-			if vv, ok := v.([]string); ok {
-				for _, vvv := range vv {
-					q.Add(k, vvv)
-				}
-			}
-			if vv := cast.ToString(v); vv != "" {
-				q.Set(k, vv)
-			}
+		q = ParseOrderedValues(query)
+	default:
+		q = NewOrderedValues()
+		if err := encodeValues(q, query, c.opts.EncodeStyle); err != nil {
+			return &EncodeError{Format: "query", Err: err}
 		}
-		r.req.URL.RawQuery = q.Encode()
 	}
+
+	// merge with the query in uri keeping its order and encoding
+	base := ParseOrderedValues(c.req.URL.RawQuery)
+	base.merge(q, c.opts.QueryMode)
+
+	c.req.URL.RawQuery = base.Encode()
+
+	return nil
 }
 
-func (r *Request) parseCookies() {
-	switch r.opts.Cookies.(type) {
+func (c *call) parseCookies() {
+	switch c.opts.Cookies.(type) {
 	case string:
-		cookies := r.opts.Cookies.(string)
-		r.req.Header.Add("Cookie", cookies)
+		cookies := c.opts.Cookies.(string)
+		c.req.Header.Add("Cookie", cookies)
 	case map[string]string:
-		cookies := r.opts.Cookies.(map[string]string)
+		cookies := c.opts.Cookies.(map[string]string)
 		for k, v := range cookies {
-			r.req.AddCookie(&http.Cookie{
+			c.req.AddCookie(&http.Cookie{
 				Name:  k,
 				Value: v,
 			})
 		}
 	case map[string]interface{}:
-		cookies := r.opts.Cookies.(map[string]interface{})
+		cookies := c.opts.Cookies.(map[string]interface{})
 		for k, v := range cookies {
-			r.req.AddCookie(&http.Cookie{
+			c.req.AddCookie(&http.Cookie{
 				Name:  k,
 				Value: cast.ToString(v),
 			})
 		}
 	case []*http.Cookie:
-		cookies := r.opts.Cookies.([]*http.Cookie)
+		cookies := c.opts.Cookies.([]*http.Cookie)
 		for _, cookie := range cookies {
-			r.req.AddCookie(cookie)
+			c.req.AddCookie(cookie)
 		}
 	}
 }
 
-func (r *Request) parseHeaders() {
-	if r.opts.Headers != nil {
-		for k, v := range r.opts.Headers {
+func (c *call) parseHeaders() {
+	if c.opts.Headers != nil {
+		for k, v := range c.opts.Headers {
 			if vv, ok := v.(string); ok {
-				r.req.Header.Set(k, vv)
+				c.req.Header.Set(k, vv)
 				continue
 			}
 			if vv, ok := v.([]string); ok {
 				for _, vvv := range vv {
-					r.req.Header.Add(k, vvv)
+					c.req.Header.Add(k, vvv)
 				}
 			}
 			if vv := cast.ToString(v); vv != "" {
-				r.req.Header.Set(k, vv)
+				c.req.Header.Set(k, vv)
 			}
 		}
 	}
+
+	// content length is sent from request field, not from headers
+	if v := c.req.Header.Get("Content-Length"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			c.req.ContentLength = n
+		}
+		c.req.Header.Del("Content-Length")
+	}
 }
 
-func (r *Request) parseBody() {
+func (c *call) parseBody() error {
+	// raw body
+	if c.opts.Body != nil {
+		return c.parseRawBody()
+	}
+
+	// payload encoded by the codec of the content type
+	if c.opts.Payload != nil {
+		return c.parsePayload()
+	}
+
 	// application/x-www-form-urlencoded
-	if r.opts.FormParams != nil {
-		if _, ok := r.opts.Headers["Content-Type"]; !ok {
-			r.opts.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	if c.opts.FormParams != nil {
+		if _, ok := c.opts.Headers["Content-Type"]; !ok {
+			c.opts.Headers["Content-Type"] = "application/x-www-form-urlencoded"
 		}
 
-		values := url.Values{}
-		for k, v := range r.opts.FormParams {
-			if vv, ok := v.(string); ok {
-				values.Set(k, vv)
-			}
-			if vv, ok := v.([]string); ok {
-				for _, vvv := range vv {
-					values.Add(k, vvv)
-				}
-			}
-			if vv := cast.ToString(v); vv != "" {
-				values.Set(k, vv)
-			}
+		values := NewOrderedValues()
+		if err := encodeValues(values, c.opts.FormParams, c.opts.EncodeStyle); err != nil {
+			return &EncodeError{Format: "form", Err: err}
 		}
-		r.body = strings.NewReader(values.Encode())
+		c.body = strings.NewReader(values.Encode())
 
-		return
+		return nil
 	}
 
 	// application/json
-	if r.opts.JSON != nil {
-		if _, ok := r.opts.Headers["Content-Type"]; !ok {
-			r.opts.Headers["Content-Type"] = "application/json"
+	if c.opts.JSON != nil {
+		if _, ok := c.opts.Headers["Content-Type"]; !ok {
+			c.opts.Headers["Content-Type"] = "application/json"
 		}
 
-		b, err := json.Marshal(r.opts.JSON)
-		if err == nil {
-			r.body = bytes.NewReader(b)
-
-			return
+		b, err := json.Marshal(c.opts.JSON)
+		if err != nil {
+			return &EncodeError{Format: "json", Err: err}
 		}
+		c.body = bytes.NewReader(b)
+
+		return nil
 	}
 
 	// application/xml
-	if r.opts.XML != nil {
-		if _, ok := r.opts.Headers["Content-Type"]; !ok {
-			r.opts.Headers["Content-Type"] = "application/xml"
+	if c.opts.XML != nil {
+		if _, ok := c.opts.Headers["Content-Type"]; !ok {
+			c.opts.Headers["Content-Type"] = "application/xml"
 		}
 
-		switch r.opts.XML.(type) {
-		case map[string]interface{}:
-			b, err := convert.Map2Xml(r.opts.XML.(map[string]interface{}))
-			if err == nil {
-				r.body = bytes.NewBuffer(b)
+		var b []byte
+		var err error
 
-				return
-			}
+		switch params := c.opts.XML.(type) {
+		case map[string]interface{}:
+			b, err = convert.Map2Xml(params)
 		case map[string]string:
 			// 请求参数转换成xml结构
-			b, err := convert.Map2Xml(r.opts.XML.(map[string]interface{}))
-			if err == nil {
-				r.body = bytes.NewBuffer(b)
-
-				return
+			m := make(map[string]interface{}, len(params))
+			for k, v := range params {
+				m[k] = v
 			}
+			b, err = convert.Map2Xml(m)
 		default:
-			b, err := xml.Marshal(r.opts.XML)
-			if err == nil {
-				r.body = bytes.NewBuffer(b)
-			}
+			b, err = xml.Marshal(params)
 		}
-	}
-
-	// multipart/form-data
-	if r.opts.Multipart != nil {
-		if _, ok := r.opts.Headers["Content-Type"]; !ok {
-			r.opts.Headers["Content-Type"] = "multipart/form-data"
+		if err != nil {
+			return &EncodeError{Format: "xml", Err: err}
 		}
+		c.body = bytes.NewReader(b)
 
-		buf := new(bytes.Buffer)
-		bw := multipart.NewWriter(buf)
+		return nil
+	}
 
-		for _, v := range r.opts.Multipart {
-			if v.Headers == nil {
-				v.Headers = map[string]interface{}{}
+	// multipart/form-data, streamed without loading files into memory
+	if c.opts.Multipart != nil {
+		form := newMultipartForm(c.opts.Multipart)
+
+		c.body = form.body(c.opts.UploadProgress)
+		c.contentLength = form.contentLength()
+		if form.replayable() {
+			// copies read before sending, e.g. to sign the body,
+			// don't report progress, bodies sent again do
+			c.getBody = func() (io.ReadCloser, error) {
+				return form.body(nil), nil
 			}
-			if v.Filepath != "" {
-				if v.Filename == "" {
-					v.Filename = filepath.Base(v.Filepath)
-				}
-				if v.Contents == nil {
-					f, err := os.Open(v.Filepath)
-					if err == nil {
-						defer f.Close()
-
-						fi, err := f.Stat()
-						if err == nil {
-							size := fi.Size()
-
-							fd := make([]byte, size)
-							f.Read(fd)
-							v.Contents = fd
-
-							if _, ok := v.Headers["Content-Type"]; !ok {
-								v.Headers["Content-Type"] = http.DetectContentType(fd)
-							}
-						}
-					}
-				}
+			c.replayBody = func() (io.ReadCloser, error) {
+				return form.body(c.opts.UploadProgress), nil
 			}
+		}
+		c.opts.Headers["Content-Type"] = form.contentType()
+	}
 
-			arr := []string{
-				"form-data",
-				fmt.Sprintf("name=%q", v.Name),
-			}
-			if v.Filename != "" {
-				arr = append(arr, fmt.Sprintf("filename=%q", v.Filename))
-			}
+	return nil
+}
+
+func (c *call) parseRawBody() error {
+	switch body := c.opts.Body.(type) {
+	case []byte:
+		if _, ok := c.opts.Headers["Content-Type"]; !ok {
+			c.opts.Headers["Content-Type"] = "application/octet-stream"
+		}
 
-			h := make(textproto.MIMEHeader)
+		c.body = bytes.NewReader(body)
+	case string:
+		if _, ok := c.opts.Headers["Content-Type"]; !ok {
+			c.opts.Headers["Content-Type"] = "text/plain; charset=utf-8"
+		}
 
-			// set content disposition
-			h.Set("Content-Disposition", strings.Join(arr, "; "))
+		c.body = strings.NewReader(body)
+	case *bytes.Reader, *strings.Reader, *bytes.Buffer:
+		// measured and replayed by http.NewRequest
+		c.body = body.(io.Reader)
+	case io.ReadSeeker:
+		// replayed from its current position, left open for the caller
+		start, err := body.Seek(0, io.SeekCurrent)
+		if err != nil {
+			c.body = body
+			return nil
+		}
+		size := int64(-1)
+		if end, err := body.Seek(0, io.SeekEnd); err == nil {
+			size = end - start
+			c.contentLength = size
+		}
+		body.Seek(start, io.SeekStart)
+
+		// files are read through independent sections, reading a copy
+		// before sending, e.g. to sign it, doesn't consume the body
+		if ra, ok := body.(io.ReaderAt); ok && size >= 0 {
+			c.body = io.NewSectionReader(ra, start, size)
+			c.getBody = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(io.NewSectionReader(ra, start, size)), nil
+			}
+			return nil
+		}
 
-			// set headers
-			for key, value := range v.Headers {
-				if header, ok := value.(string); ok {
-					h.Set(key, header)
-				}
+		c.body = &rewindReader{rs: body, start: start}
+		c.getBody = func() (io.ReadCloser, error) {
+			if _, err := body.Seek(start, io.SeekStart); err != nil {
+				return nil, err
 			}
+			return ioutil.NopCloser(body), nil
+		}
+	case io.Reader:
+		c.body = body
+	default:
+		return &EncodeError{
+			Format: "body",
+			Err:    fmt.Errorf("unsupported body type %T", body),
+		}
+	}
+
+	return nil
+}
 
-			p, _ := bw.CreatePart(h)
-			io.Copy(p, bytes.NewReader(v.Contents))
+func (c *call) parsePayload() error {
+	contentType := ""
+	for k, v := range c.opts.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			contentType = cast.ToString(v)
 		}
+	}
+	if contentType == "" {
+		contentType = "application/json"
+		c.opts.Headers["Content-Type"] = contentType
+	}
 
-		bw.Close()
+	codec, ok := c.codecs[mediaType(contentType)]
+	if !ok {
+		return &EncodeError{
+			Format: contentType,
+			Err:    fmt.Errorf("no codec registered for content type %q", contentType),
+		}
+	}
 
-		r.body = buf
-		r.opts.Headers["Content-Type"] = bw.FormDataContentType()
+	b, err := codec.Marshal(c.opts.Payload)
+	if err != nil {
+		return &EncodeError{Format: contentType, Err: err}
 	}
+	c.body = bytes.NewReader(b)
+
+	return nil
 }
@@ -1,7 +1,12 @@
 package goz
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"strings"
@@ -12,11 +17,34 @@ import (
 
 // Response response object
 type Response struct {
+	ctx    context.Context
 	resp   *http.Response
 	req    *http.Request
 	body   []byte
 	stream chan []byte
 	err    error
+
+	attempts int
+	strict   bool
+	unread   bool
+	codecs   map[string]Codec
+
+	redirects []Redirect
+	trace     *tracer
+}
+
+// NewResponse build response object from a http response and read its body,
+// middlewares can use it to replace the response
+func NewResponse(req *http.Request, resp *http.Response) *Response {
+	r := &Response{
+		ctx:      req.Context(),
+		resp:     resp,
+		req:      req,
+		attempts: 1,
+	}
+	r.readBody(0)
+
+	return r
 }
 
 // ResponseBody response body
@@ -58,6 +86,11 @@ func (r *Response) GetParsedBody() (*gjson.Result, error) {
 	return &pb, nil
 }
 
+// GetAttempts get how many times the request was sent, retries included
+func (r *Response) GetAttempts() int {
+	return r.attempts
+}
+
 // GetStatusCode get response status code
 func (r *Response) GetStatusCode() int {
 	return r.resp.StatusCode
@@ -76,8 +109,8 @@ func (r *Response) IsTimeout() bool {
 	if r.err == nil {
 		return false
 	}
-	netErr, ok := r.err.(net.Error)
-	if !ok {
+	var netErr net.Error
+	if !errors.As(r.err, &netErr) {
 		return false
 	}
 	if netErr.Timeout() {
@@ -114,6 +147,11 @@ func (r *Response) GetHeaderLine(name string) string {
 	return ""
 }
 
+// GetCookies get cookies set by the response
+func (r *Response) GetCookies() []*http.Cookie {
+	return r.resp.Cookies()
+}
+
 // HasHeader get if header exsits in response headers
 func (r *Response) HasHeader(name string) bool {
 	headers := r.GetHeaders()
@@ -136,6 +174,46 @@ func (r *Response) Stream() chan []byte {
 	return r.stream
 }
 
+// read response body, stream response is read in background,
+// the body size is limited by maxSize when it's positive
+func (r *Response) readBody(maxSize int64) {
+	if strings.HasPrefix(r.GetHeaderLine("content-type"), "text/event-stream") {
+		r.parseSteam()
+		return
+	}
+
+	var reader io.Reader = r.resp.Body
+	if maxSize > 0 {
+		reader = io.LimitReader(r.resp.Body, maxSize+1)
+	}
+
+	body, err := ioutil.ReadAll(reader)
+	r.resp.Body.Close()
+
+	r.body = body
+	r.err = err
+
+	// body read aborted by context
+	if err != nil && r.ctx.Err() != nil {
+		r.err = r.ctx.Err()
+	}
+
+	if maxSize > 0 && int64(len(body)) > maxSize {
+		r.body = body[:maxSize]
+		r.err = fmt.Errorf("%w: limit %d bytes", ErrBodyTooLarge, maxSize)
+	}
+}
+
+// BodyReader get response body reader, the body is not buffered
+// when Options.StreamBody is set and the caller must close it
+func (r *Response) BodyReader() io.ReadCloser {
+	if r.unread {
+		return r.resp.Body
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(r.body))
+}
+
 // parse response stream
 func (r *Response) parseSteam() {
 	r.stream = make(chan []byte)
@@ -148,7 +226,13 @@ func (r *Response) parseSteam() {
 		for {
 			event, err := decoder.Decode()
 			if err != nil {
-				r.err = fmt.Errorf("decode data failed: %v", err)
+				if ctxErr := r.ctx.Err(); ctxErr != nil {
+					// stream aborted by context
+					r.err = ctxErr
+					return
+				}
+
+				r.err = &StreamError{Err: err}
 				return
 			}
 
@@ -158,7 +242,12 @@ func (r *Response) parseSteam() {
 				return
 			}
 
-			r.stream <- []byte(data)
+			select {
+			case r.stream <- []byte(data):
+			case <-r.ctx.Done():
+				r.err = r.ctx.Err()
+				return
+			}
 		}
 	}()
 }
@@ -0,0 +1,207 @@
+package goz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 0.1
+	defaultRetryMaxDelay  = 10
+)
+
+// defaultRetryStatusCodes status codes retried when Retry.StatusCodes is empty
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// Retry retry policy, delays are in seconds like Options.Timeout
+type Retry struct {
+	// MaxAttempts total attempts including the first one
+	MaxAttempts int
+	// BaseDelay delay before the first retry, doubled on each attempt, default 0.1
+	BaseDelay float32
+	// MaxDelay cap of the backoff delay, default 10
+	MaxDelay float32
+	// Jitter ratio in [0, 1] of the delay that is randomized
+	Jitter float32
+	// StatusCodes response status codes to retry, default 429, 502, 503 and 504
+	StatusCodes []int
+	// Retryable decide if a transport error should be retried,
+	// default retry network errors
+	Retryable func(err error) bool
+	// RetryAfter honour the Retry-After response header, capped by MaxDelay
+	RetryAfter bool
+}
+
+// shouldRetry get if the attempt result should be retried
+func (p *Retry) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		if p.Retryable != nil {
+			return p.Retryable(err)
+		}
+
+		var netErr net.Error
+		return errors.As(err, &netErr)
+	}
+
+	codes := p.StatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryStatusCodes
+	}
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff get the delay before the next attempt
+func (p *Retry) backoff(attempt int, resp *http.Response) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	max := p.MaxDelay
+	if max <= 0 {
+		max = defaultRetryMaxDelay
+	}
+
+	if p.RetryAfter && resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return minDuration(delay, seconds(max))
+		}
+	}
+
+	delay := float64(base) * math.Pow(2, float64(attempt-1))
+	if delay > float64(max) {
+		delay = float64(max)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * float64(p.Jitter) * rand.Float64()
+	}
+
+	return time.Duration(delay * float64(time.Second))
+}
+
+// parseRetryAfter parse Retry-After header in seconds or http date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			secs = 0
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		delay := time.Until(t)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// do send the request, retry it following the retry policy
+func (c *call) do(ctx context.Context) (*http.Response, int, error) {
+	policy := c.opts.Retry
+	if policy == nil || policy.MaxAttempts <= 1 {
+		c.redirects = nil
+		c.trace.reset()
+		resp, err := c.roundTrip()
+		return resp, 1, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		// keep the redirects of the last attempt only
+		c.redirects = nil
+		c.trace.reset()
+		resp, err := c.roundTrip()
+
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || !policy.shouldRetry(resp, err) {
+			return resp, attempt, err
+		}
+		if !c.replayable() {
+			return resp, attempt, err
+		}
+
+		delay := policy.backoff(attempt, resp)
+
+		if rerr := c.rewind(resp); rerr != nil {
+			return nil, attempt, rerr
+		}
+
+		if c.opts.Debug {
+			log.Printf("retry attempt %d in %v, last error: %v\n", attempt+1, delay, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempt, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// replayable get if the request body can be sent again
+func (c *call) replayable() bool {
+	return c.req.Body == nil || c.req.Body == http.NoBody || c.req.GetBody != nil
+}
+
+// rewind discard the previous response and reset the request body,
+// shared by retries and auth challenges before sending the request again
+func (c *call) rewind(resp *http.Response) error {
+	if resp != nil {
+		// drain body so the connection can be reused
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	getBody := c.req.GetBody
+	if c.replayBody != nil {
+		getBody = c.replayBody
+	}
+	if getBody == nil {
+		return nil
+	}
+
+	body, err := getBody()
+	if err != nil {
+		return err
+	}
+	c.req.Body = body
+
+	return nil
+}
+
+func seconds(s float32) time.Duration {
+	return time.Duration(s*1000) * time.Millisecond
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,288 @@
+package goz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSegmentAttempts attempts of each segment when Options.Retry is not set
+const defaultSegmentAttempts = 3
+
+// segment byte range of the downloading file
+type segment struct {
+	start int64
+	end   int64
+}
+
+// downloadSegments download file in parallel range requests written at their offset,
+// ok is false when the server doesn't support ranges. the returned response
+// describes the whole file, errors return the response of the probe request
+func (r *Request) downloadSegments(ctx context.Context, uri, path string, merged Options, opts []Options) (*Response, bool, error) {
+	// probe file size and range support
+	resp, err := r.rangeRequest(ctx, uri, merged, opts, "bytes=0-0", "")
+	if err != nil {
+		return resp, true, err
+	}
+	resp.BodyReader().Close()
+
+	if resp.GetStatusCode() != http.StatusPartialContent {
+		return resp, false, nil
+	}
+	size, ok := parseContentRangeSize(resp.GetHeaderLine("Content-Range"))
+	if !ok || size <= 0 {
+		return resp, false, nil
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.part")
+	if err != nil {
+		return resp, true, err
+	}
+	part := f.Name()
+
+	err = r.writeSegments(ctx, f, uri, size, resp.validator(), merged, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && merged.Checksum != "" {
+		err = verifyChecksum(part, merged.Checksum)
+	}
+	if err == nil {
+		err = os.Rename(part, path)
+	}
+	if err != nil {
+		os.Remove(part)
+		return resp, true, err
+	}
+
+	return resp.completed(size), true, nil
+}
+
+// completed describe the downloaded file instead of the probed range,
+// the body was written to the file so it is empty
+func (r *Response) completed(size int64) *Response {
+	resp := *r.resp
+	resp.Status = "200 OK"
+	resp.StatusCode = http.StatusOK
+	resp.ContentLength = size
+	resp.Body = http.NoBody
+
+	resp.Header = r.resp.Header.Clone()
+	resp.Header.Del("Content-Range")
+	resp.Header.Set("Content-Length", strconv.FormatInt(size, 10))
+
+	done := *r
+	done.resp = &resp
+
+	return &done
+}
+
+// writeSegments fetch segments concurrently, the first failed segment cancels the others
+func (r *Request) writeSegments(ctx context.Context, f *os.File, uri string, size int64, validator string, merged Options, opts []Options) error {
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	progress := &segmentProgress{
+		total:    size,
+		progress: merged.DownloadProgress,
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	for _, seg := range splitSegments(size, int64(merged.DownloadSegments)) {
+		wg.Add(1)
+		go func(seg segment) {
+			defer wg.Done()
+
+			if err := r.fetchSegment(ctx, f, uri, seg, validator, merged, opts, progress); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(seg)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// fetchSegment fetch a segment, retry from the last written byte on failure
+// following the backoff of Options.Retry
+func (r *Request) fetchSegment(ctx context.Context, f *os.File, uri string, seg segment, validator string, merged Options, opts []Options, progress *segmentProgress) error {
+	var policy Retry
+	if merged.Retry != nil {
+		policy = *merged.Retry
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultSegmentAttempts
+	}
+
+	offset := seg.start
+	for attempt := 1; ; attempt++ {
+		err := r.fetchRange(ctx, f, uri, &offset, seg.end, validator, merged, opts, progress)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= policy.MaxAttempts {
+			return fmt.Errorf("download segment %d-%d failed: %w", seg.start, seg.end, err)
+		}
+
+		// honour Retry-After of a failed status
+		var resp *http.Response
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			resp = &http.Response{StatusCode: statusErr.StatusCode, Header: statusErr.Header}
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt, resp))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// fetchRange fetch bytes from offset to end and write them at their position
+func (r *Request) fetchRange(ctx context.Context, f *os.File, uri string, offset *int64, end int64, validator string, merged Options, opts []Options, progress *segmentProgress) error {
+	rangeHeader := fmt.Sprintf("bytes=%d-%d", *offset, end)
+
+	// segments are retried by fetchSegment, send each range once
+	opts = append(opts[:len(opts):len(opts)], Options{Retry: &Retry{MaxAttempts: 1}})
+
+	resp, err := r.rangeRequest(ctx, uri, merged, opts, rangeHeader, validator)
+	if err != nil {
+		return err
+	}
+
+	body := resp.BodyReader()
+	defer body.Close()
+
+	switch resp.GetStatusCode() {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return errors.New("file changed while downloading segments")
+	default:
+		snippet, _ := ioutil.ReadAll(io.LimitReader(body, statusErrorBodySize))
+		return newStatusError(resp.resp, snippet)
+	}
+
+	buf := make([]byte, 32*1024)
+	for *offset <= end {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if int64(n) > end-*offset+1 {
+				n = int(end - *offset + 1)
+			}
+			if _, werr := f.WriteAt(buf[:n], *offset); werr != nil {
+				return werr
+			}
+			*offset += int64(n)
+			progress.add(int64(n))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if *offset <= end {
+		return io.ErrUnexpectedEOF
+	}
+
+	return nil
+}
+
+// splitSegments split size into n segments
+func splitSegments(size, n int64) []segment {
+	if n > size {
+		n = size
+	}
+
+	segments := make([]segment, 0, n)
+	step := size / n
+	for i := int64(0); i < n; i++ {
+		seg := segment{
+			start: i * step,
+			end:   (i+1)*step - 1,
+		}
+		if i == n-1 {
+			seg.end = size - 1
+		}
+		segments = append(segments, seg)
+	}
+
+	return segments
+}
+
+// parseContentRangeSize parse complete length from Content-Range: bytes 0-0/1234
+func parseContentRangeSize(contentRange string) (int64, bool) {
+	i := strings.LastIndex(contentRange, "/")
+	if !strings.HasPrefix(contentRange, "bytes ") || i < 0 {
+		return 0, false
+	}
+
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return size, true
+}
+
+// parseContentRangeStart parse first byte position from Content-Range: bytes 100-199/1234
+func parseContentRangeStart(contentRange string) (int64, bool) {
+	i := strings.Index(contentRange, "-")
+	if !strings.HasPrefix(contentRange, "bytes ") || i < 0 {
+		return 0, false
+	}
+
+	start, err := strconv.ParseInt(strings.TrimSpace(contentRange[len("bytes "):i]), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return start, true
+}
+
+// segmentProgress report bytes written by all segments
+type segmentProgress struct {
+	mu       sync.Mutex
+	done     int64
+	total    int64
+	progress func(done, total int64)
+}
+
+func (p *segmentProgress) add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done += n
+	if p.progress != nil {
+		p.progress(p.done, p.total)
+	}
+}
@@ -0,0 +1,199 @@
+package goz
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unsignedPayload body hash of bodies that can't be read before sending
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// Signer sign requests, it's called before every attempt after
+// the query, headers and body are built
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// SignerFunc use a function as Signer
+type SignerFunc func(req *http.Request) error
+
+// Sign implement Signer
+func (f SignerFunc) Sign(req *http.Request) error {
+	return f(req)
+}
+
+// HMACSigner sign requests with hmac-sha256 over the lines
+//
+//	METHOD
+//	/escaped/path
+//	sorted=query&params
+//	unix timestamp
+//	name:value of each signed header
+//	hex sha256 of body or UNSIGNED-PAYLOAD
+//
+// the signature is sent as
+// HMAC-SHA256 KeyId=<KeyID>, SignedHeaders=<a;b>, Signature=<hex>
+type HMACSigner struct {
+	KeyID  string
+	Secret string
+
+	// Headers signed headers, SignatureHeader defaults to Authorization,
+	// TimestampHeader defaults to X-Timestamp
+	Headers         []string
+	SignatureHeader string
+	TimestampHeader string
+
+	// Base64 encode the signature with base64 instead of hex
+	Base64 bool
+}
+
+// Sign implement Signer
+func (s *HMACSigner) Sign(req *http.Request) error {
+	bodyHash, err := bodySHA256(req)
+	if err != nil {
+		return err
+	}
+
+	timestampHeader := s.TimestampHeader
+	if timestampHeader == "" {
+		timestampHeader = "X-Timestamp"
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(timestampHeader, timestamp)
+
+	lines := []string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.RawQuery),
+		timestamp,
+	}
+
+	names := make([]string, 0, len(s.Headers))
+	for _, name := range s.Headers {
+		name = strings.ToLower(name)
+		names = append(names, name)
+		lines = append(lines, name+":"+canonicalHeaderValue(req, name))
+	}
+	lines = append(lines, bodyHash)
+
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	io.WriteString(mac, strings.Join(lines, "\n"))
+
+	var signature string
+	if s.Base64 {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	} else {
+		signature = hex.EncodeToString(mac.Sum(nil))
+	}
+
+	signatureHeader := s.SignatureHeader
+	if signatureHeader == "" {
+		signatureHeader = "Authorization"
+	}
+	req.Header.Set(signatureHeader, "HMAC-SHA256 KeyId="+s.KeyID+", SignedHeaders="+strings.Join(names, ";")+", Signature="+signature)
+
+	return nil
+}
+
+// bodySHA256 hex sha256 of the request body, read from a replayable copy
+func bodySHA256(req *http.Request) (string, error) {
+	h := sha256.New()
+
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return unsignedPayload, nil
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+
+		if _, err := io.Copy(h, body); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalQuery query sorted by escaped key then escaped value, escaped as rfc 3986
+func canonicalQuery(rawQuery string) string {
+	values, _ := url.ParseQuery(rawQuery)
+
+	type pair struct {
+		key   string
+		value string
+	}
+
+	pairs := make([]pair, 0, len(values))
+	for k, vs := range values {
+		for _, v := range vs {
+			pairs = append(pairs, pair{key: uriEscape(k, true), value: uriEscape(v, true)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].key != pairs[j].key {
+			return pairs[i].key < pairs[j].key
+		}
+		return pairs[i].value < pairs[j].value
+	})
+
+	encoded := make([]string, len(pairs))
+	for i, p := range pairs {
+		encoded[i] = p.key + "=" + p.value
+	}
+
+	return strings.Join(encoded, "&")
+}
+
+// canonicalHeaderValue header values trimmed, spaces collapsed and joined with comma
+func canonicalHeaderValue(req *http.Request, name string) string {
+	if strings.EqualFold(name, "host") {
+		if req.Host != "" {
+			return req.Host
+		}
+		return req.URL.Host
+	}
+
+	values := req.Header[http.CanonicalHeaderKey(name)]
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.Join(strings.Fields(v), " ")
+	}
+
+	return strings.Join(trimmed, ",")
+}
+
+// uriEscape escape everything but rfc 3986 unreserved characters,
+// slashes are kept unless encodeSlash is set
+func uriEscape(s string, encodeSlash bool) string {
+	const hexChars = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~',
+			c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexChars[c>>4])
+			b.WriteByte(hexChars[c&15])
+		}
+	}
+
+	return b.String()
+}
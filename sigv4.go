@@ -0,0 +1,139 @@
+package goz
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sigV4Algorithm aws signature version 4 algorithm name
+const sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// SigV4 aws signature version 4 signer, the host, content type and x-amz-*
+// headers are signed along with SignedHeaders. s3 requests send the body
+// hash in x-amz-content-sha256 and their path is escaped only once
+type SigV4 struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Service         string
+
+	// SignedHeaders extra headers to sign
+	SignedHeaders []string
+
+	// UnsignedPayload skip hashing the body, only supported by s3
+	UnsignedPayload bool
+}
+
+// Sign implement Signer
+func (s *SigV4) Sign(req *http.Request) error {
+	return s.SignAt(req, time.Now())
+}
+
+// SignAt sign request as if sent at t, e.g. to check known signatures
+func (s *SigV4) SignAt(req *http.Request, t time.Time) error {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	payloadHash := unsignedPayload
+	if !s.UnsignedPayload {
+		hash, err := bodySHA256(req)
+		if err != nil {
+			return err
+		}
+		payloadHash = hash
+	}
+
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	if s.Service == "s3" || payloadHash == unsignedPayload {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	names := s.signedHeaders(req)
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + canonicalHeaderValue(req, name) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	path = uriEscape(path, false)
+	if s.Service != "s3" {
+		// other services expect the escaped path escaped again
+		path = uriEscape(path, false)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.RawQuery),
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	for _, part := range []string{s.Region, s.Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", sigV4Algorithm+" Credential="+s.AccessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+
+	return nil
+}
+
+// signedHeaders sorted lowercase names of the signed headers
+func (s *SigV4) signedHeaders(req *http.Request) []string {
+	seen := map[string]bool{"host": true}
+	for name := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "content-md5" || strings.HasPrefix(name, "x-amz-") {
+			seen[name] = true
+		}
+	}
+	for _, name := range s.SignedHeaders {
+		seen[strings.ToLower(name)] = true
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	io.WriteString(mac, data)
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,127 @@
+package goz
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TraceInfo timing of the last attempt, durations of phases that didn't
+// happen are zero, e.g. dns, connect and tls for a reused connection.
+// FirstByte is measured from the start of the attempt, Total until the
+// body is read, or until the response headers with Options.StreamBody
+type TraceInfo struct {
+	DNSLookup    time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	FirstByte    time.Duration
+	Total        time.Duration
+	ConnReused   bool
+	RemoteAddr   string
+}
+
+// tracer collect httptrace events of a call
+type tracer struct {
+	mu sync.Mutex
+	traceTimes
+}
+
+// traceTimes event times of an attempt
+type traceTimes struct {
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	end          time.Time
+	reused       bool
+	remoteAddr   string
+}
+
+// reset start tracing an attempt
+func (t *tracer) reset() {
+	t.mu.Lock()
+	t.traceTimes = traceTimes{start: time.Now()}
+	t.mu.Unlock()
+}
+
+// finish mark the end of the call
+func (t *tracer) finish() {
+	t.mu.Lock()
+	t.end = time.Now()
+	t.mu.Unlock()
+}
+
+// clientTrace httptrace hooks, they may be called from other goroutines
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	now := func(field *time.Time) {
+		t.mu.Lock()
+		*field = time.Now()
+		t.mu.Unlock()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { now(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { now(&t.dnsDone) },
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			// keep the first of parallel dials
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				now(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { now(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { now(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			if addr := info.Conn.RemoteAddr(); addr != nil {
+				t.remoteAddr = addr.String()
+			}
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() { now(&t.firstByte) },
+	}
+}
+
+// info durations of the traced phases
+func (t *tracer) info() TraceInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	between := func(start, end time.Time) time.Duration {
+		if start.IsZero() || end.IsZero() {
+			return 0
+		}
+		return end.Sub(start)
+	}
+
+	return TraceInfo{
+		DNSLookup:    between(t.dnsStart, t.dnsDone),
+		Connect:      between(t.connectStart, t.connectDone),
+		TLSHandshake: between(t.tlsStart, t.tlsDone),
+		FirstByte:    between(t.start, t.firstByte),
+		Total:        between(t.start, t.end),
+		ConnReused:   t.reused,
+		RemoteAddr:   t.remoteAddr,
+	}
+}
+
+// TraceInfo get timing of the request
+func (r *Response) TraceInfo() TraceInfo {
+	if r.trace == nil {
+		return TraceInfo{}
+	}
+
+	return r.trace.info()
+}
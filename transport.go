@@ -0,0 +1,160 @@
+package goz
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90
+	defaultDialTimeout         = 30
+	defaultTLSHandshakeTimeout = 10
+)
+
+// newTLSConfig build tls config from options, server certificates are verified
+// unless InsecureSkipVerify is set
+func newTLSConfig(opts Options) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		Certificates:       opts.Certificates,
+		MinVersion:         opts.TLSMinVersion,
+		MaxVersion:         opts.TLSMaxVersion,
+		CipherSuites:       opts.CipherSuites,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.RootCAFile != "" || len(opts.RootCAPEM) > 0 {
+		pool := x509.NewCertPool()
+
+		if opts.RootCAFile != "" {
+			pem, err := ioutil.ReadFile(opts.RootCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("read root ca file failed: %v", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificate found in root ca file %s", opts.RootCAFile)
+			}
+		}
+		if len(opts.RootCAPEM) > 0 {
+			if !pool.AppendCertsFromPEM(opts.RootCAPEM) {
+				return nil, errors.New("no certificate found in root ca pem")
+			}
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if hasPins(opts) {
+		tlsConfig.VerifyPeerCertificate = verifyPins(opts.PublicKeyPins, opts.CertificatePins)
+	}
+
+	if hasClientCertificateFiles(opts) {
+		if opts.ReloadCertificates {
+			reloader, err := newCertReloader(opts)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.GetClientCertificate = reloader.GetClientCertificate
+		} else {
+			cert, err := loadClientCertificate(opts)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.Certificates = append(append([]tls.Certificate{}, opts.Certificates...), *cert)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// newTransport build a transport with connection pool settings from options
+func newTransport(opts Options) (*http.Transport, error) {
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	maxIdleConns := opts.MaxIdleConns
+	if maxIdleConns == 0 {
+		maxIdleConns = defaultMaxIdleConns
+	}
+	maxIdleConnsPerHost := opts.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost == 0 {
+		maxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	idleConnTimeout := opts.IdleConnTimeout
+	if idleConnTimeout == 0 {
+		idleConnTimeout = defaultIdleConnTimeout
+	}
+	dialTimeout := opts.DialTimeout
+	if dialTimeout == 0 {
+		dialTimeout = defaultDialTimeout
+	}
+	tlsHandshakeTimeout := opts.TLSHandshakeTimeout
+	if tlsHandshakeTimeout == 0 {
+		tlsHandshakeTimeout = defaultTLSHandshakeTimeout
+	}
+
+	tr := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   seconds(dialTimeout),
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   seconds(tlsHandshakeTimeout),
+		ResponseHeaderTimeout: seconds(opts.ResponseHeaderTimeout),
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       seconds(idleConnTimeout),
+		DisableKeepAlives:     opts.DisableKeepAlives,
+	}
+
+	if opts.Proxy != "" {
+		proxy, err := url.Parse(opts.Proxy)
+		if err == nil {
+			tr.Proxy = http.ProxyURL(proxy)
+		}
+	}
+
+	return tr, nil
+}
+
+// transportOverridden get if per request options change transport settings,
+// in which case the shared transport of the client can't be used
+func transportOverridden(opts ...Options) bool {
+	for _, opt := range opts {
+		if opt.Proxy != "" || opt.Certificates != nil {
+			return true
+		}
+		if opt.MaxIdleConns > 0 || opt.MaxIdleConnsPerHost > 0 || opt.MaxConnsPerHost > 0 {
+			return true
+		}
+		if opt.IdleConnTimeout > 0 || opt.DisableKeepAlives {
+			return true
+		}
+		if opt.DialTimeout > 0 || opt.TLSHandshakeTimeout > 0 || opt.ResponseHeaderTimeout > 0 {
+			return true
+		}
+		if opt.RootCAFile != "" || opt.RootCAPEM != nil || opt.InsecureSkipVerify {
+			return true
+		}
+		if hasClientCertificateFiles(opt) || opt.ReloadCertificates || hasPins(opt) {
+			return true
+		}
+		if opt.TLSMinVersion > 0 || opt.TLSMaxVersion > 0 || opt.CipherSuites != nil || opt.ServerName != "" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,394 @@
+package goz
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// tagOptions options of the url struct tag: `url:"name,omitempty,comma"`
+type tagOptions struct {
+	omitEmpty bool
+	// slice style: repeat (default), comma or brackets
+	comma    bool
+	brackets bool
+	unix     bool
+	layout   string
+}
+
+// EncodeStyle encoding style of nested objects and arrays in Query and FormParams
+type EncodeStyle int
+
+const (
+	// EncodeDefault keep the style of the client, EncodeRepeat if it isn't set
+	EncodeDefault EncodeStyle = iota
+	// EncodeRepeat a[b]=1&list=x&list=y, the default
+	EncodeRepeat
+	// EncodeBrackets a[b]=1&list[]=x&list[]=y, as PHP and Rails expect
+	EncodeBrackets
+	// EncodeIndices a[b]=1&list[0]=x&list[1]=y
+	EncodeIndices
+	// EncodeDotted a.b=1&list.0=x&list.1=y
+	EncodeDotted
+)
+
+// valuesEncoder encode maps and structs into values
+type valuesEncoder struct {
+	values valuesWriter
+	style  EncodeStyle
+}
+
+// encodeValues encode map or struct into values, shared by Query and FormParams.
+// struct fields are named by the url tag and written in order, map keys are sorted,
+// nested structs and maps are encoded following the style, nil pointers are omitted
+func encodeValues(values valuesWriter, v interface{}, style EncodeStyle) error {
+	e := &valuesEncoder{values: values, style: style}
+
+	return e.encode(v)
+}
+
+func (e *valuesEncoder) encode(v interface{}) error {
+	values := e.values
+
+	switch params := v.(type) {
+	case nil:
+		return nil
+	case *OrderedValues:
+		for _, p := range params.pairs {
+			values.Add(p.key, p.value)
+		}
+		return nil
+	case url.Values:
+		for _, k := range sortedKeys(params) {
+			for _, vv := range params[k] {
+				values.Add(k, vv)
+			}
+		}
+		return nil
+	case map[string]string:
+		for _, k := range sortedKeys(params) {
+			values.Set(k, params[k])
+		}
+		return nil
+	}
+
+	rv, ok := indirect(reflect.ValueOf(v))
+	if !ok {
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return e.encodeStruct("", rv)
+	case reflect.Map:
+		return e.encodeMap("", rv)
+	}
+
+	return fmt.Errorf("unsupported params type %T", v)
+}
+
+// objectKey key of a nested object field
+func (e *valuesEncoder) objectKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	if e.style == EncodeDotted {
+		return prefix + "." + name
+	}
+
+	return prefix + "[" + name + "]"
+}
+
+// indexKey key of an array item
+func (e *valuesEncoder) indexKey(name string, i int) string {
+	if e.style == EncodeDotted {
+		return fmt.Sprintf("%s.%d", name, i)
+	}
+
+	return fmt.Sprintf("%s[%d]", name, i)
+}
+
+func (e *valuesEncoder) encodeStruct(prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			// unexported
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+		opts.layout = field.Tag.Get("layout")
+
+		fv := rv.Field(i)
+
+		// embedded struct fields are promoted
+		if field.Anonymous && name == "" {
+			ev, ok := indirect(fv)
+			if !ok {
+				continue
+			}
+			if ev.Kind() == reflect.Struct {
+				if err := e.encodeStruct(prefix, ev); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		name = e.objectKey(prefix, name)
+
+		if opts.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+
+		if err := e.encodeValue(name, fv, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *valuesEncoder) encodeMap(prefix string, rv reflect.Value) error {
+	if rv.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("unsupported map key type %s", rv.Type().Key())
+	}
+
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	for _, key := range keys {
+		name := e.objectKey(prefix, key.String())
+
+		if err := e.encodeValue(name, rv.MapIndex(key), tagOptions{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *valuesEncoder) encodeValue(name string, rv reflect.Value, opts tagOptions) error {
+	values := e.values
+
+	rv, ok := indirect(rv)
+	if !ok {
+		// nil pointers are omitted
+		return nil
+	}
+
+	if rv.Type() == timeType {
+		values.Set(name, formatTime(rv.Interface().(time.Time), opts))
+		return nil
+	}
+	if rv.Type().Implements(textMarshalerType) {
+		b, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		values.Set(name, string(b))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return e.encodeStruct(name, rv)
+	case reflect.Map:
+		return e.encodeMap(name, rv)
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte as string
+			values.Set(name, string(rv.Bytes()))
+			return nil
+		}
+		return e.encodeSlice(name, rv, opts)
+	}
+
+	s, err := formatScalar(rv)
+	if err != nil {
+		return fmt.Errorf("encode %s failed: %v", name, err)
+	}
+	values.Set(name, s)
+
+	return nil
+}
+
+func (e *valuesEncoder) encodeSlice(name string, rv reflect.Value, opts tagOptions) error {
+	values := e.values
+
+	// scalar items keyed by their index
+	indices := make([]int, 0, rv.Len())
+	items := make([]string, 0, rv.Len())
+
+	for i := 0; i < rv.Len(); i++ {
+		item, ok := indirect(rv.Index(i))
+		if !ok {
+			continue
+		}
+
+		if item.Type() == timeType {
+			indices = append(indices, i)
+			items = append(items, formatTime(item.Interface().(time.Time), opts))
+			continue
+		}
+		if item.Kind() == reflect.Struct || item.Kind() == reflect.Map || item.Kind() == reflect.Slice {
+			// nested values are indexed: name[0][field]
+			if err := e.encodeValue(e.indexKey(name, i), item, opts); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, err := formatScalar(item)
+		if err != nil {
+			return fmt.Errorf("encode %s failed: %v", name, err)
+		}
+		indices = append(indices, i)
+		items = append(items, s)
+	}
+
+	// tag options take precedence over the encoder style
+	switch {
+	case opts.comma:
+		if len(items) > 0 {
+			values.Set(name, strings.Join(items, ","))
+		}
+	case opts.brackets || e.style == EncodeBrackets:
+		for _, item := range items {
+			values.Add(name+"[]", item)
+		}
+	case e.style == EncodeIndices || e.style == EncodeDotted:
+		for i, item := range items {
+			values.Set(e.indexKey(name, indices[i]), item)
+		}
+	default:
+		for _, item := range items {
+			values.Add(name, item)
+		}
+	}
+
+	return nil
+}
+
+// sortedKeys get sorted keys of a string keyed map
+func sortedKeys(m interface{}) []string {
+	rv := reflect.ValueOf(m)
+
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// indirect dereference pointers and interfaces, ok is false for nil
+func indirect(rv reflect.Value) (reflect.Value, bool) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return rv, false
+		}
+		rv = rv.Elem()
+	}
+
+	return rv, rv.IsValid()
+}
+
+func formatScalar(rv reflect.Value) (string, error) {
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	}
+
+	if s, ok := rv.Interface().(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+
+	return "", fmt.Errorf("unsupported value type %s", rv.Type())
+}
+
+func formatTime(t time.Time, opts tagOptions) string {
+	if opts.unix {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	if opts.layout != "" {
+		return t.Format(opts.layout)
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func parseTag(tag string) (string, tagOptions) {
+	arr := strings.Split(tag, ",")
+
+	opts := tagOptions{}
+	for _, opt := range arr[1:] {
+		switch opt {
+		case "omitempty":
+			opts.omitEmpty = true
+		case "comma":
+			opts.comma = true
+		case "brackets":
+			opts.brackets = true
+		case "unix":
+			opts.unix = true
+		}
+	}
+
+	return arr[0], opts
+}
+
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	}
+
+	if rv.Type() == timeType {
+		return rv.Interface().(time.Time).IsZero()
+	}
+
+	return false
+}